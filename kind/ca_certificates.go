@@ -0,0 +1,70 @@
+package kind
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	"sigs.k8s.io/kind/pkg/errors"
+)
+
+const (
+	// nodeCACertificatesDir is picked up by update-ca-certificates on the
+	// Debian based kindest/node images.
+	nodeCACertificatesDir = "/usr/local/share/ca-certificates"
+	// nodeCACertificatePrefix marks the certificates managed by the provider so
+	// they can be replaced without touching anything else in the trust store.
+	nodeCACertificatePrefix = "terraform-provider-kind-"
+)
+
+// installTrustedCACertificates replaces the provider managed CA certificates
+// in the trust store of every cluster node and restarts containerd so image
+// pulls pick them up.
+func installTrustedCACertificates(provider *cluster.Provider, clusterName string, certificates []string) error {
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	// the external load balancer doesn't pull images and has no trust store tooling
+	internalNodes, err := nodeutils.InternalNodes(allNodes)
+	if err != nil {
+		return err
+	}
+
+	fns := []func() error{}
+	for _, node := range internalNodes {
+		node := node // capture loop variable
+		fns = append(fns, func() error {
+			return installNodeCACertificates(node, certificates)
+		})
+	}
+	return errors.UntilErrorConcurrent(fns)
+}
+
+func installNodeCACertificates(node nodes.Node, certificates []string) error {
+	cleanup := fmt.Sprintf("rm -f %s/%s*.crt", nodeCACertificatesDir, nodeCACertificatePrefix)
+	if err := node.Command("sh", "-c", cleanup).Run(); err != nil {
+		return fmt.Errorf("failed to remove previous CA certificates on node %s: %s", node.String(), err)
+	}
+
+	for i, certificate := range certificates {
+		dest := path.Join(nodeCACertificatesDir, fmt.Sprintf("%s%d.crt", nodeCACertificatePrefix, i))
+		if !strings.HasSuffix(certificate, "\n") {
+			certificate += "\n"
+		}
+		if err := nodeutils.WriteFile(node, dest, certificate); err != nil {
+			return fmt.Errorf("failed to write CA certificate to node %s: %s", node.String(), err)
+		}
+	}
+
+	if err := node.Command("update-ca-certificates").Run(); err != nil {
+		return fmt.Errorf("failed to update CA certificates on node %s: %s", node.String(), err)
+	}
+	if err := node.Command("systemctl", "restart", "containerd").Run(); err != nil {
+		return fmt.Errorf("failed to restart containerd on node %s: %s", node.String(), err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/constants"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// image labels recording where a cluster image was committed from
+const (
+	clusterImageLabelPrefix  = "terraform-provider-kind.cluster-image."
+	clusterImageClusterLabel = clusterImageLabelPrefix + "cluster"
+	clusterImageNodeLabel    = clusterImageLabelPrefix + "node"
+	clusterImageRoleLabel    = clusterImageLabelPrefix + "role"
+	clusterImageIPLabel      = clusterImageLabelPrefix + "ip"
+)
+
+// nodeRootfsMount is where a node's root filesystem is bind mounted to reach
+// the /var directory hidden by kind's /var volume.
+const nodeRootfsMount = "/kind/rootfs"
+
+// node files that contain the node IP
+var nodeIPFiles = []string{
+	"/etc/kubernetes/manifests/*.yaml",
+	"/var/lib/kubelet/kubeadm-flags.env",
+	"/kind/kubeadm.conf",
+}
+
+// clusterImageNode is a node image committed from a cluster.
+type clusterImageNode struct {
+	Image   string
+	Cluster string
+	Node    string
+	Role    string
+	IP      string
+}
+
+// clusterImageTag returns the tag a node is committed as, the node name
+// without the cluster name, e.g. control-plane or worker2.
+func clusterImageTag(clusterName, nodeName string) string {
+	return strings.TrimPrefix(nodeName, clusterName+"-")
+}
+
+// clusterImageNetwork returns the network cloned clusters run on. Each clone
+// has its own network so the node names of the original cluster can be used
+// as aliases without clashing with other clones.
+func clusterImageNetwork(clusterName string) string {
+	return "kind-" + clusterName
+}
+
+// commitClusterImages commits every node of a cluster, including the content
+// of its /var volume, into repository:<node tag> images. Kubernetes is
+// stopped on each node while it is committed so etcd and containerd state are
+// consistent. It returns the image IDs by image reference.
+func commitClusterImages(ctx context.Context, provider *cluster.Provider, clusterName, repository string) (map[string]string, error) {
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	lb, err := nodeutils.ExternalLoadBalancerNode(allNodes)
+	if err != nil {
+		return nil, err
+	}
+	if lb != nil {
+		return nil, fmt.Errorf("cluster %q has multiple control plane nodes, only clusters with a single control plane can be committed", clusterName)
+	}
+
+	images := map[string]string{}
+	for _, node := range allNodes {
+		ref := fmt.Sprintf("%s:%s", repository, clusterImageTag(clusterName, node.String()))
+		id, err := commitNodeImage(ctx, node, clusterName, ref)
+		if err != nil {
+			return images, err
+		}
+		images[ref] = id
+	}
+	return images, nil
+}
+
+func commitNodeImage(ctx context.Context, node nodes.Node, clusterName, ref string) (string, error) {
+	ctx = tflog.SetField(ctx, "node", node.String())
+	tflog.Info(ctx, "Committing node", map[string]interface{}{"image": ref})
+
+	role, err := node.Role()
+	if err != nil {
+		return "", err
+	}
+	ipv4, _, err := node.IP()
+	if err != nil {
+		return "", fmt.Errorf("failed to get IP of node %s: %s", node.String(), err)
+	}
+
+	clearRootfsVar := fmt.Sprintf("mkdir -p %[1]s && mount --bind / %[1]s && find %[1]s/var -mindepth 1 -maxdepth 1 -exec rm -rf {} + ; umount %[1]s", nodeRootfsMount)
+	prepare := strings.Join([]string{
+		"systemctl stop kubelet",
+		"(crictl ps -q | xargs -r crictl stop)",
+		"systemctl stop containerd",
+		fmt.Sprintf("mkdir -p %[1]s && mount --bind / %[1]s", nodeRootfsMount),
+		fmt.Sprintf("find %[1]s/var -mindepth 1 -maxdepth 1 -exec rm -rf {} +", nodeRootfsMount),
+		fmt.Sprintf("cp -a /var/. %s/var/", nodeRootfsMount),
+		fmt.Sprintf("umount %s", nodeRootfsMount),
+	}, " && ")
+	restore := clearRootfsVar + " ; systemctl start containerd kubelet"
+	defer func() {
+		if lines, err := exec.CombinedOutputLines(node.Command("sh", "-c", restore)); err != nil {
+			tflog.Warn(ctx, "Unable to restart Kubernetes on node", map[string]interface{}{"error": err.Error(), "output": lines})
+		}
+	}()
+
+	if lines, err := exec.CombinedOutputLines(node.Command("sh", "-c", prepare)); err != nil {
+		return "", fmt.Errorf("failed to prepare node %s for commit: %s: %v", node.String(), err, lines)
+	}
+
+	args := []string{"commit"}
+	for k, v := range map[string]string{
+		clusterImageClusterLabel: clusterName,
+		clusterImageNodeLabel:    node.String(),
+		clusterImageRoleLabel:    role,
+		clusterImageIPLabel:      ipv4,
+	} {
+		args = append(args, "--change", fmt.Sprintf("LABEL %s=%q", k, v))
+	}
+	args = append(args, node.String(), ref)
+	if lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), args...)); err != nil {
+		return "", fmt.Errorf("failed to commit node %s: %s: %v", node.String(), err, lines)
+	}
+	return localImageID(ref)
+}
+
+// listClusterImages returns the node images committed to a repository,
+// control plane first.
+func listClusterImages(repository string) ([]clusterImageNode, error) {
+	lines, err := exec.OutputLines(exec.Command(containerRuntime(), "images", "--format", "{{ .Tag }}", repository))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images of %q: %s", repository, err)
+	}
+
+	images := []clusterImageNode{}
+	for _, tag := range lines {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "<none>" {
+			continue
+		}
+		ref := fmt.Sprintf("%s:%s", repository, tag)
+		labels, err := exec.OutputLines(exec.Command(containerRuntime(), "image", "inspect", "-f",
+			fmt.Sprintf(`{{ index .Config.Labels %q }}|{{ index .Config.Labels %q }}|{{ index .Config.Labels %q }}|{{ index .Config.Labels %q }}`,
+				clusterImageClusterLabel, clusterImageNodeLabel, clusterImageRoleLabel, clusterImageIPLabel),
+			ref))
+		if err != nil || len(labels) != 1 {
+			return nil, fmt.Errorf("failed to inspect image %q: %v", ref, err)
+		}
+		parts := strings.Split(labels[0], "|")
+		if len(parts) != 4 || parts[0] == "" || parts[1] == "" {
+			// not committed by kind_cluster_image
+			continue
+		}
+		images = append(images, clusterImageNode{Image: ref, Cluster: parts[0], Node: parts[1], Role: parts[2], IP: parts[3]})
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		ci := images[i].Role == constants.ControlPlaneNodeRoleValue
+		cj := images[j].Role == constants.ControlPlaneNodeRoleValue
+		if ci != cj {
+			return ci
+		}
+		return images[i].Node < images[j].Node
+	})
+
+	controlPlanes := 0
+	for _, img := range images {
+		if img.Role == constants.ControlPlaneNodeRoleValue {
+			controlPlanes++
+		}
+	}
+	if controlPlanes != 1 {
+		return nil, fmt.Errorf("expected images of exactly one control plane node in %q, found %d", repository, controlPlanes)
+	}
+	return images, nil
+}
+
+// createClusterFromImages creates a cluster from node images committed by
+// kind_cluster_image. The nodes keep the hostnames of the original cluster,
+// which are also network aliases on the clone's own network, so kubeconfigs
+// and certificates inside the cluster stay valid. Node IPs in the kubelet and
+// static pod configuration are replaced with the new ones. With retain the
+// nodes are kept on failure, like cluster.CreateWithRetain.
+func createClusterFromImages(ctx context.Context, provider *cluster.Provider, clusterName, repository, kubeconfigPath string, retain bool, timeout time.Duration) (err error) {
+	images, err := listClusterImages(repository)
+	if err != nil {
+		return err
+	}
+
+	network := clusterImageNetwork(clusterName)
+	if lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "network", "create",
+		"-o", "com.docker.network.bridge.enable_ip_masquerade=true", network)); err != nil {
+		return fmt.Errorf("failed to create network %q: %s: %v", network, err, lines)
+	}
+	defer func() {
+		if err != nil && !retain {
+			provider.Delete(clusterName, kubeconfigPath)
+			if err := removeClusterImageNetwork(clusterName); err != nil {
+				tflog.Warn(ctx, "Unable to remove network of cloned cluster", map[string]interface{}{"error": err.Error()})
+			}
+		}
+	}()
+
+	for _, img := range images {
+		nodeName := clusterName + strings.TrimPrefix(img.Node, img.Cluster)
+		args := nodeRunArgs(clusterName, nodeName, img.Node, img.Role, network, v1alpha4.IPv4Family)
+		args = append(args, "--network-alias", img.Node)
+		if img.Role == constants.ControlPlaneNodeRoleValue {
+			args = append(args, "-e", "KUBECONFIG=/etc/kubernetes/admin.conf", "--publish=127.0.0.1::6443/TCP")
+		}
+		args = append(args, img.Image)
+		if lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), args...)); err != nil {
+			return fmt.Errorf("failed to create node %s from %s: %s: %v", nodeName, img.Image, err, lines)
+		}
+	}
+
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	oldIPs := map[string]string{}
+	for _, img := range images {
+		oldIPs[clusterName+strings.TrimPrefix(img.Node, img.Cluster)] = img.IP
+	}
+	for _, node := range allNodes {
+		if err := waitForNodeBoot(node, nodeBootTimeout); err != nil {
+			return err
+		}
+		if err := replaceNodeIP(node, oldIPs[node.String()]); err != nil {
+			return err
+		}
+	}
+
+	controlPlane, err := nodeutils.BootstrapControlPlaneNode(allNodes)
+	if err != nil {
+		return err
+	}
+	if err := waitForAPIServer(ctx, controlPlane, timeout); err != nil {
+		return err
+	}
+	return withKubeconfigLock(ctx, kubeconfigPath, func() error {
+		return provider.ExportKubeConfig(clusterName, kubeconfigPath, false)
+	})
+}
+
+// replaceNodeIP replaces the IP a node had in the original cluster with its
+// current one and restarts the kubelet, which recreates the static pods.
+func replaceNodeIP(node nodes.Node, oldIP string) error {
+	newIP, _, err := node.IP()
+	if err != nil {
+		return fmt.Errorf("failed to get IP of node %s: %s", node.String(), err)
+	}
+	if oldIP != "" && oldIP != newIP {
+		sed := fmt.Sprintf(`sed -i 's/\b%s\b/%s/g' %s 2>/dev/null; true`,
+			regexp.QuoteMeta(oldIP), newIP, strings.Join(nodeIPFiles, " "))
+		if err := node.Command("sh", "-c", sed).Run(); err != nil {
+			return fmt.Errorf("failed to update IP of node %s: %s", node.String(), err)
+		}
+	}
+	if err := node.Command("systemctl", "restart", "kubelet").Run(); err != nil {
+		return fmt.Errorf("failed to restart kubelet on node %s: %s", node.String(), err)
+	}
+	return nil
+}
+
+// removeClusterImageNetwork removes the network of a cloned cluster.
+func removeClusterImageNetwork(clusterName string) error {
+	network := clusterImageNetwork(clusterName)
+	if lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "network", "rm", network)); err != nil {
+		return fmt.Errorf("failed to remove network %q: %s: %v", network, err, lines)
+	}
+	return nil
+}
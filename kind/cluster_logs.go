@@ -0,0 +1,152 @@
+package kind
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/cluster"
+)
+
+// failureLogTailLines is how many lines of each node's kubelet and containerd
+// logs are included in the error of a failed cluster creation.
+const failureLogTailLines = 20
+
+// handleCreateFailure collects the logs of a cluster whose creation failed and
+// whose nodes were retained, then deletes the nodes unless they should be kept
+// for debugging. The returned error wraps createErr with the log location and
+// the tail of the kubelet and containerd logs.
+func handleCreateFailure(provider *cluster.Provider, name, kubeconfigPath, logsDir string, retain bool, createErr error) error {
+	msg := &strings.Builder{}
+
+	dir := filepath.Join(logsDir, name)
+	if logsDir == "" {
+		dir = clusterLogsDir(name)
+		defer os.RemoveAll(dir)
+	}
+	// logs of an earlier failure would be mixed up with the new ones
+	if err := resetLogsDir(dir); err != nil {
+		return fmt.Errorf("failed to create cluster %q: %w\n\n%s", name, createErr, err)
+	}
+
+	if err := provider.CollectLogs(name, dir); err != nil {
+		fmt.Fprintf(msg, "\n\nfailed to collect logs: %s", err)
+	} else {
+		if logsDir != "" {
+			fmt.Fprintf(msg, "\n\nCluster logs were exported to %s", dir)
+		}
+		msg.WriteString(failureLogTails(dir, failureLogTailLines))
+	}
+
+	if retain {
+		fmt.Fprintf(msg, "\n\nThe nodes of cluster %q were retained for debugging, run `terraform destroy` or `kind delete cluster --name %s` to remove them.", name, name)
+		return fmt.Errorf("failed to create cluster %q: %w%s", name, createErr, msg)
+	}
+
+	if err := provider.Delete(name, kubeconfigPath); err != nil {
+		fmt.Fprintf(msg, "\n\nfailed to delete nodes of cluster %q: %s", name, err)
+	}
+	return fmt.Errorf("failed to create cluster %q: %w%s", name, createErr, msg)
+}
+
+// failureLogTails returns the last lines of the kubelet and containerd logs of
+// every node found in a directory populated by CollectLogs.
+func failureLogTails(dir string, lines int) string {
+	b := &strings.Builder{}
+	nodeDirs, _ := filepath.Glob(filepath.Join(dir, "*", "kubelet.log"))
+	sort.Strings(nodeDirs)
+	for _, kubeletLog := range nodeDirs {
+		nodeDir := filepath.Dir(kubeletLog)
+		node := filepath.Base(nodeDir)
+		for _, file := range []string{"kubelet.log", "containerd.log"} {
+			tail, err := tailFile(filepath.Join(nodeDir, file), lines)
+			if err != nil || len(tail) == 0 {
+				continue
+			}
+			fmt.Fprintf(b, "\n\n%s on %s (last %d lines):\n%s", file, node, len(tail), strings.Join(tail, "\n"))
+		}
+	}
+	return b.String()
+}
+
+// tailFile returns up to the last n lines of a file.
+func tailFile(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tail := make([]string, 0, n)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(tail) == n {
+			tail = tail[1:]
+		}
+		tail = append(tail, scanner.Text())
+	}
+	return tail, scanner.Err()
+}
+
+// clusterLogsDir returns the directory logs of a cluster are exported to if
+// no directory was given. It is stable so repeated exports replace the
+// previous logs instead of piling up temporary directories.
+func clusterLogsDir(name string) string {
+	return filepath.Join(os.TempDir(), "terraform-provider-kind", "logs", name)
+}
+
+// resetLogsDir removes the contents of a directory logs are exported to.
+func resetLogsDir(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove previous logs in %q: %s", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create logs directory %q: %s", dir, err)
+	}
+	return nil
+}
+
+// collectClusterLogs exports the logs of all nodes of a cluster into dir and
+// returns the collected files relative to dir, sorted.
+func collectClusterLogs(provider *cluster.Provider, name, dir string) ([]string, error) {
+	found, err := clusterExists(provider, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("kind cluster %q not found", name)
+	}
+
+	if err := provider.CollectLogs(name, dir); err != nil {
+		return nil, fmt.Errorf("failed to collect logs of cluster %q: %s", name, err)
+	}
+	return listLogFiles(dir)
+}
+
+// listLogFiles returns the regular files below dir relative to it, sorted.
+func listLogFiles(dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collected logs in %q: %s", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,86 @@
+package kind
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTailFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubelet.log")
+	lines := []string{}
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tail, err := tailFile(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"line 28", "line 29", "line 30"}; !reflect.DeepEqual(tail, expected) {
+		t.Errorf("expected %v, got %v", expected, tail)
+	}
+
+	tail, err = tailFile(path, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tail) != 30 {
+		t.Errorf("expected all 30 lines, got %d", len(tail))
+	}
+}
+
+func TestFailureLogTails(t *testing.T) {
+	dir := t.TempDir()
+	node := filepath.Join(dir, "test-control-plane")
+	if err := os.MkdirAll(node, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(node, "kubelet.log"), []byte("kubelet started\nkubelet failed\n"), 0644)
+	os.WriteFile(filepath.Join(node, "containerd.log"), []byte("containerd started\n"), 0644)
+
+	out := failureLogTails(dir, 1)
+	for _, expected := range []string{
+		"kubelet.log on test-control-plane (last 1 lines):\nkubelet failed",
+		"containerd.log on test-control-plane (last 1 lines):\ncontainerd started",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got %q", expected, out)
+		}
+	}
+	if strings.Contains(out, "kubelet started") {
+		t.Errorf("expected only the last line, got %q", out)
+	}
+}
+
+func TestResetLogsDir(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	dir := clusterLogsDir("test")
+	if dir != clusterLogsDir("test") || dir == clusterLogsDir("other") {
+		t.Errorf("expected a stable directory per cluster, got %q", dir)
+	}
+
+	stale := filepath.Join(dir, "test-control-plane", "kubelet.log")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := resetLogsDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	files, err := listLogFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected the previous logs to be removed, got %v", files)
+	}
+}
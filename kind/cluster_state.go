@@ -0,0 +1,152 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/constants"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// nodeStartOrder is the order node containers are started in, they are stopped
+// in reverse. The load balancer comes first so the control planes can reach
+// each other through it, workers last so they join a serving API server.
+var nodeStartOrder = []string{
+	constants.ExternalLoadBalancerNodeRoleValue,
+	constants.ControlPlaneNodeRoleValue,
+	constants.WorkerNodeRoleValue,
+}
+
+// clusterExists reports whether kind knows a cluster of the given name.
+func clusterExists(provider *cluster.Provider, clusterName string) (bool, error) {
+	clusters, err := provider.List()
+	if err != nil {
+		return false, fmt.Errorf("failed to list kind clusters: %s", err)
+	}
+	for _, c := range clusters {
+		if c == clusterName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// clusterRunning reports whether all node containers of a cluster are running.
+func clusterRunning(provider *cluster.Provider, clusterName string) (bool, error) {
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return false, fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	if len(allNodes) == 0 {
+		return false, fmt.Errorf("no nodes found for cluster %q", clusterName)
+	}
+	for _, node := range allNodes {
+		running, err := nodeRunning(node)
+		if err != nil {
+			return false, err
+		}
+		if !running {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func nodeRunning(node nodes.Node) (bool, error) {
+	lines, err := exec.OutputLines(
+		exec.Command(containerRuntime(), "inspect", "-f", "{{ .State.Running }}", node.String()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect node %s: %s", node.String(), err)
+	}
+	if len(lines) != 1 {
+		return false, fmt.Errorf("expected 1 line of output, got %d", len(lines))
+	}
+	return strings.TrimSpace(lines[0]) == "true", nil
+}
+
+// nodesInStartOrder returns the nodes of a cluster grouped by role in
+// nodeStartOrder.
+func nodesInStartOrder(provider *cluster.Provider, clusterName string) ([]nodes.Node, error) {
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	ordered := []nodes.Node{}
+	for _, role := range nodeStartOrder {
+		selected, err := nodeutils.SelectNodesByRole(allNodes, role)
+		if err != nil {
+			return nil, err
+		}
+		ordered = append(ordered, selected...)
+	}
+	return ordered, nil
+}
+
+// stopCluster stops all node containers of a cluster, workers first and the
+// load balancer last. The containers and their state are kept.
+func stopCluster(ctx context.Context, provider *cluster.Provider, clusterName string) error {
+	ordered, err := nodesInStartOrder(provider, clusterName)
+	if err != nil {
+		return err
+	}
+	for i := len(ordered) - 1; i >= 0; i-- {
+		tflog.Info(ctx, "Stopping node", map[string]interface{}{"node": ordered[i].String()})
+		lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "stop", ordered[i].String()))
+		if err != nil {
+			return fmt.Errorf("failed to stop node %s: %s: %v", ordered[i].String(), err, lines)
+		}
+	}
+	return nil
+}
+
+// startCluster starts all node containers of a cluster in nodeStartOrder and
+// waits up to timeout for the API server to be ready again.
+func startCluster(ctx context.Context, provider *cluster.Provider, clusterName string, timeout time.Duration) error {
+	ordered, err := nodesInStartOrder(provider, clusterName)
+	if err != nil {
+		return err
+	}
+	for _, node := range ordered {
+		tflog.Info(ctx, "Starting node", map[string]interface{}{"node": node.String()})
+		lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "start", node.String()))
+		if err != nil {
+			return fmt.Errorf("failed to start node %s: %s: %v", node.String(), err, lines)
+		}
+	}
+
+	controlPlanes, err := nodeutils.SelectNodesByRole(ordered, constants.ControlPlaneNodeRoleValue)
+	if err != nil {
+		return err
+	}
+	if len(controlPlanes) == 0 {
+		return fmt.Errorf("no control plane nodes found for cluster %q", clusterName)
+	}
+	return waitForAPIServer(ctx, controlPlanes[0], timeout)
+}
+
+// waitForAPIServer polls the readiness endpoint of the API server through the
+// admin kubeconfig of a control plane node, which points at the load balancer
+// for HA clusters, until it is ready, timeout passed or ctx is done.
+func waitForAPIServer(ctx context.Context, node nodes.Node, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		err := node.CommandContext(ctx, "kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "get", "--raw=/readyz").Run()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for the API server on node %s: %w", node.String(), ctx.Err())
+		case <-deadline:
+			return fmt.Errorf("API server on node %s not ready after %s: %s", node.String(), timeout, err)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
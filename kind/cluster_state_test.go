@@ -0,0 +1,48 @@
+package kind
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// unreadyNode is a node whose commands fail, like kubectl while the API
+// server isn't ready.
+type unreadyNode struct {
+	nodes.Node
+}
+
+func (n unreadyNode) String() string { return "test-control-plane" }
+
+func (n unreadyNode) Command(command string, args ...string) exec.Cmd {
+	return exec.Command("false")
+}
+
+func (n unreadyNode) CommandContext(ctx context.Context, command string, args ...string) exec.Cmd {
+	return exec.CommandContext(ctx, "false")
+}
+
+func TestWaitForAPIServer_Cancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := waitForAPIServer(ctx, unreadyNode{}, time.Minute)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected waiting to stop with the context, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected waiting to stop right away, took %s", elapsed)
+	}
+}
+
+func TestWaitForAPIServer_Timeout(t *testing.T) {
+	err := waitForAPIServer(context.Background(), unreadyNode{}, 100*time.Millisecond)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the API server not to be ready, got %v", err)
+	}
+}
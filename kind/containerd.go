@@ -0,0 +1,205 @@
+package kind
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// containerdCertsDir is where containerd looks up per registry host
+// configuration once config_path is set.
+const containerdCertsDir = "/etc/containerd/certs.d"
+
+// containerdConfigPathPatch enables per registry host configuration in
+// containerd, see https://github.com/containerd/containerd/blob/main/docs/hosts.md
+var containerdConfigPathPatch = fmt.Sprintf(`[plugins."io.containerd.grpc.v1.cri".registry]
+  config_path = %q
+`, containerdCertsDir)
+
+// writeRegistryHostsToml writes the hosts.toml for a registry host onto a node.
+// containerd reads these files on every pull so no restart is required.
+func writeRegistryHostsToml(node nodes.Node, host, content string) error {
+	dir := path.Join(containerdCertsDir, host)
+	if err := node.Command("mkdir", "-p", dir).Run(); err != nil {
+		return fmt.Errorf("failed to create %s on node %s: %s", dir, node.String(), err)
+	}
+	if err := nodeutils.WriteFile(node, path.Join(dir, "hosts.toml"), content); err != nil {
+		return fmt.Errorf("failed to write hosts.toml for %s on node %s: %s", host, node.String(), err)
+	}
+	return nil
+}
+
+// registryMirror is the provider side representation of a kind_config
+// registry_mirror block.
+type registryMirror struct {
+	Registry   string
+	Endpoints  []string
+	SkipVerify bool
+	CAFile     string
+	Username   string
+	Password   string
+}
+
+// hostDir returns the directory holding the mirror's configuration on a node.
+func (m registryMirror) hostDir() string {
+	return path.Join(containerdCertsDir, m.Registry)
+}
+
+// server returns the upstream registry containerd falls back to when none of
+// the mirror endpoints can serve a pull.
+func (m registryMirror) server() string {
+	if m.Registry == "docker.io" {
+		return "https://registry-1.docker.io"
+	}
+	return "https://" + m.Registry
+}
+
+// hostsToml renders the containerd hosts.toml for the mirror.
+func (m registryMirror) hostsToml() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server = %q\n", m.server())
+	for _, endpoint := range m.Endpoints {
+		fmt.Fprintf(&b, "\n[host.%q]\n", endpoint)
+		b.WriteString("  capabilities = [\"pull\", \"resolve\"]\n")
+		if m.SkipVerify {
+			b.WriteString("  skip_verify = true\n")
+		}
+		if m.CAFile != "" {
+			fmt.Fprintf(&b, "  ca = %q\n", path.Join(m.hostDir(), "ca.crt"))
+		}
+		if m.Username != "" || m.Password != "" {
+			auth := base64.StdEncoding.EncodeToString([]byte(m.Username + ":" + m.Password))
+			fmt.Fprintf(&b, "  [host.%q.header]\n", endpoint)
+			fmt.Fprintf(&b, "    Authorization = [%q]\n", "Basic "+auth)
+		}
+	}
+	return b.String()
+}
+
+// registryMirrorFiles are the files of a mirror on a node.
+type registryMirrorFiles struct {
+	Registry  string
+	HostsToml string
+	CA        string
+}
+
+// expectedFiles returns the files the mirror's configuration results in,
+// reading its ca_file from the host.
+func (m registryMirror) expectedFiles() (registryMirrorFiles, error) {
+	files := registryMirrorFiles{Registry: m.Registry, HostsToml: m.hostsToml()}
+	if m.CAFile != "" {
+		data, err := os.ReadFile(m.CAFile)
+		if err != nil {
+			return files, fmt.Errorf("failed to read ca_file for registry mirror %q: %s", m.Registry, err)
+		}
+		files.CA = string(data)
+	}
+	return files, nil
+}
+
+// nodeFiles returns the files of the mirror present on a node, missing files
+// are empty.
+func (m registryMirror) nodeFiles(node nodes.Node) registryMirrorFiles {
+	files := registryMirrorFiles{Registry: m.Registry}
+	if data, err := exec.Output(node.Command("cat", path.Join(m.hostDir(), "hosts.toml"))); err == nil {
+		files.HostsToml = string(data)
+	}
+	if m.CAFile != "" {
+		if data, err := exec.Output(node.Command("cat", path.Join(m.hostDir(), "ca.crt"))); err == nil {
+			files.CA = string(data)
+		}
+	}
+	return files
+}
+
+// registryMirrorsDigest hashes the files of all mirrors.
+func registryMirrorsDigest(files []registryMirrorFiles) string {
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", f.Registry, f.HostsToml, f.CA)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// expectedRegistryMirrorsDigest returns the digest of the files the mirrors
+// result in on every node.
+func expectedRegistryMirrorsDigest(mirrors []registryMirror) (string, error) {
+	files := make([]registryMirrorFiles, 0, len(mirrors))
+	for _, m := range mirrors {
+		f, err := m.expectedFiles()
+		if err != nil {
+			return "", err
+		}
+		files = append(files, f)
+	}
+	return registryMirrorsDigest(files), nil
+}
+
+// nodesRegistryMirrorsDigest returns the digest of the mirror files present
+// on the nodes without changing them. It is empty if the nodes differ, e.g.
+// because one of them was recreated without the files.
+func nodesRegistryMirrorsDigest(nodeList []nodes.Node, mirrors []registryMirror) string {
+	digest := ""
+	for i, node := range nodeList {
+		files := make([]registryMirrorFiles, 0, len(mirrors))
+		for _, m := range mirrors {
+			files = append(files, m.nodeFiles(node))
+		}
+		d := registryMirrorsDigest(files)
+		if i > 0 && d != digest {
+			return ""
+		}
+		digest = d
+	}
+	return digest
+}
+
+// applyRegistryMirrors writes the hosts.toml and CA certificate of every
+// mirror onto the nodes. Files already up to date are left alone, so this can
+// be called again to repair nodes that lost or changed their configuration.
+func applyRegistryMirrors(nodeList []nodes.Node, mirrors []registryMirror) error {
+	for _, m := range mirrors {
+		expected, err := m.expectedFiles()
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodeList {
+			if m.nodeFiles(node) == expected {
+				continue
+			}
+			if expected.CA != "" {
+				if err := node.Command("mkdir", "-p", m.hostDir()).Run(); err != nil {
+					return fmt.Errorf("failed to create %s on node %s: %s", m.hostDir(), node.String(), err)
+				}
+				if err := nodeutils.WriteFile(node, path.Join(m.hostDir(), "ca.crt"), expected.CA); err != nil {
+					return fmt.Errorf("failed to write ca.crt for %s on node %s: %s", m.Registry, node.String(), err)
+				}
+			}
+			if err := writeRegistryHostsToml(node, m.Registry, expected.HostsToml); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendContainerdConfigPathPatch adds the config_path patch to the kind
+// config unless it is already present.
+func appendContainerdConfigPathPatch(cfg *v1alpha4.Cluster) {
+	for _, p := range cfg.ContainerdConfigPatches {
+		if p == containerdConfigPathPatch {
+			return
+		}
+	}
+	cfg.ContainerdConfigPatches = append(cfg.ContainerdConfigPatches, containerdConfigPathPatch)
+}
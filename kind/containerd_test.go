@@ -0,0 +1,158 @@
+package kind
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+func TestRegistryMirrorHostsToml(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Mirror   registryMirror
+		Contains []string
+		Excludes []string
+	}{
+		{
+			Name: "DockerHubFallsBackToRegistryOne",
+			Mirror: registryMirror{
+				Registry:  "docker.io",
+				Endpoints: []string{"https://mirror.gcr.io"},
+			},
+			Contains: []string{`server = "https://registry-1.docker.io"`, `[host."https://mirror.gcr.io"]`},
+			Excludes: []string{"skip_verify", "ca =", "Authorization"},
+		},
+		{
+			Name: "AllOptions",
+			Mirror: registryMirror{
+				Registry:   "registry.example.com",
+				Endpoints:  []string{"https://a.example.com", "https://b.example.com"},
+				SkipVerify: true,
+				CAFile:     "/tmp/ca.pem",
+				Username:   "user",
+				Password:   "pass",
+			},
+			Contains: []string{
+				`server = "https://registry.example.com"`,
+				`[host."https://a.example.com"]`,
+				`[host."https://b.example.com"]`,
+				"skip_verify = true",
+				`ca = "/etc/containerd/certs.d/registry.example.com/ca.crt"`,
+				`Authorization = ["Basic dXNlcjpwYXNz"]`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hostsToml := tc.Mirror.hostsToml()
+			if _, err := normalizeToml(hostsToml); err != nil {
+				t.Fatalf("hosts.toml is not valid toml: %s\n%s", err, hostsToml)
+			}
+			for _, want := range tc.Contains {
+				if !strings.Contains(hostsToml, want) {
+					t.Errorf("expected hosts.toml to contain %q, got:\n%s", want, hostsToml)
+				}
+			}
+			for _, unwanted := range tc.Excludes {
+				if strings.Contains(hostsToml, unwanted) {
+					t.Errorf("expected hosts.toml not to contain %q, got:\n%s", unwanted, hostsToml)
+				}
+			}
+		})
+	}
+}
+
+func TestAppendContainerdConfigPathPatch(t *testing.T) {
+	cfg := &v1alpha4.Cluster{ContainerdConfigPatches: []string{"foo = 1"}}
+	appendContainerdConfigPathPatch(cfg)
+	appendContainerdConfigPathPatch(cfg)
+	if len(cfg.ContainerdConfigPatches) != 2 {
+		t.Fatalf("expected the config_path patch to be added once, got %v", cfg.ContainerdConfigPatches)
+	}
+	if cfg.ContainerdConfigPatches[1] != containerdConfigPathPatch {
+		t.Errorf("expected config_path patch to be appended, got %q", cfg.ContainerdConfigPatches[1])
+	}
+}
+
+func TestFlattenKindConfigRegistryMirrors(t *testing.T) {
+	data := map[string]interface{}{
+		"kind":        "Cluster",
+		"api_version": "kind.x-k8s.io/v1alpha4",
+		"registry_mirror": []interface{}{
+			map[string]interface{}{
+				"registry":    "docker.io",
+				"endpoints":   []interface{}{"https://mirror.gcr.io"},
+				"skip_verify": true,
+				"ca_file":     "",
+				"auth": []interface{}{
+					map[string]interface{}{"username": "user", "password": "pass"},
+				},
+			},
+		},
+	}
+
+	mirrors := flattenKindConfigRegistryMirrors(data)
+	if len(mirrors) != 1 {
+		t.Fatalf("expected 1 mirror, got %d", len(mirrors))
+	}
+	m := mirrors[0]
+	if m.Registry != "docker.io" || len(m.Endpoints) != 1 || !m.SkipVerify || m.Username != "user" || m.Password != "pass" {
+		t.Errorf("unexpected mirror %+v", m)
+	}
+
+	cfg := flattenKindConfig(data)
+	if len(cfg.ContainerdConfigPatches) != 1 || cfg.ContainerdConfigPatches[0] != containerdConfigPathPatch {
+		t.Errorf("expected config_path patch to be generated, got %v", cfg.ContainerdConfigPatches)
+	}
+}
+
+func TestExpectedRegistryMirrorsDigest(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, []byte("ca one"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mirrors := []registryMirror{{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}, CAFile: caFile}}
+
+	digest, err := expectedRegistryMirrorsDigest(mirrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := registryMirrorFiles{Registry: "docker.io", HostsToml: mirrors[0].hostsToml(), CA: "ca one"}
+	if expected := registryMirrorsDigest([]registryMirrorFiles{files}); digest != expected {
+		t.Errorf("expected %s, got %s", expected, digest)
+	}
+
+	// a changed CA certificate has to be written to the nodes again
+	if err := os.WriteFile(caFile, []byte("ca two"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := expectedRegistryMirrorsDigest(mirrors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == digest {
+		t.Error("expected the digest to change with the CA certificate")
+	}
+
+	// so does a node missing the files
+	if missing := registryMirrorsDigest([]registryMirrorFiles{{Registry: "docker.io"}}); missing == digest {
+		t.Error("expected the digest to change without files")
+	}
+
+	if err := os.Remove(caFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expectedRegistryMirrorsDigest(mirrors); err == nil {
+		t.Error("expected an error for a missing ca_file")
+	}
+}
+
+func TestNodesRegistryMirrorsDigest(t *testing.T) {
+	if digest := nodesRegistryMirrorsDigest(nil, []registryMirror{{Registry: "docker.io"}}); digest != "" {
+		t.Errorf("expected no digest without nodes, got %s", digest)
+	}
+}
@@ -0,0 +1,71 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceClusterLogs() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceKindClusterLogsRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Description: "The name of the kind cluster to export the logs of.",
+				Required:    true,
+			},
+			"output_dir": {
+				Type:        schema.TypeString,
+				Description: "The directory the logs are exported to. Defaults to a directory named after the cluster in the system temp directory, whose contents are replaced on every read.",
+				Optional:    true,
+				Computed:    true,
+			},
+			"files": {
+				Type:        schema.TypeList,
+				Description: "The exported log files, relative to output_dir.",
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceKindClusterLogsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	name := d.Get("name").(string)
+
+	dir := d.Get("output_dir").(string)
+	if dir == "" {
+		// replace the logs of the previous read instead of creating a new
+		// directory on every plan
+		dir = clusterLogsDir(name)
+		if err := resetLogsDir(dir); err != nil {
+			return errorDiagnostics("Unable to prepare directory for logs", err, nil)
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return errorDiagnostics("Invalid output directory", err, cty.GetAttrPath("output_dir"))
+	}
+
+	ctx = tflog.SetField(ctx, "cluster_name", name)
+	tflog.Info(ctx, "Exporting cluster logs", map[string]interface{}{"output_dir": dir})
+	provider := newKindProvider(ctx)
+	files, err := collectClusterLogs(provider, name, dir)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to export logs of kind cluster %q", name), err, cty.GetAttrPath("name"))
+	}
+
+	d.SetId(name)
+	d.Set("output_dir", dir)
+	d.Set("files", files)
+	return nil
+}
@@ -0,0 +1,71 @@
+package kind
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestListLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"kind-version.txt", "test-control-plane/kubelet.log", "test-control-plane/pods/kube-system_etcd/0.log"} {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("log"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := listLogFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"kind-version.txt", "test-control-plane/kubelet.log", "test-control-plane/pods/kube-system_etcd/0.log"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
+
+func TestAccClusterLogsDataSource(t *testing.T) {
+	dataSourceName := "data.kind_cluster_logs.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-cluster-logs-test")
+	outputDir := t.TempDir()
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterLogsDataSourceConfig(clusterName, outputDir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "output_dir", outputDir),
+					resource.TestCheckTypeSetElemAttr(dataSourceName, "files.*", fmt.Sprintf("%s-control-plane/kubelet.log", clusterName)),
+					resource.TestCheckTypeSetElemAttr(dataSourceName, "files.*", fmt.Sprintf("%s-control-plane/containerd.log", clusterName)),
+				),
+			},
+		},
+	})
+}
+
+func testAccClusterLogsDataSourceConfig(clusterName, outputDir string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name = "%s"
+}
+
+data "kind_cluster_logs" "test" {
+  name       = kind_cluster.test.name
+  output_dir = "%s"
+
+  depends_on = [kind_cluster.test]
+}
+`, clusterName, outputDir)
+}
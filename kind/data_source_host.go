@@ -0,0 +1,165 @@
+package kind
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// hostDataSource reports the container runtime and host limits kind clusters
+// depend on, with the result of the preflight checks.
+type hostDataSource struct{}
+
+var _ datasource.DataSource = &hostDataSource{}
+
+type hostModel struct {
+	ID                      types.String     `tfsdk:"id"`
+	Runtime                 types.String     `tfsdk:"runtime"`
+	RuntimeVersion          types.String     `tfsdk:"runtime_version"`
+	Rootless                types.Bool       `tfsdk:"rootless"`
+	CgroupVersion           types.String     `tfsdk:"cgroup_version"`
+	DataRoot                types.String     `tfsdk:"data_root"`
+	InotifyMaxUserWatches   types.Int64      `tfsdk:"inotify_max_user_watches"`
+	InotifyMaxUserInstances types.Int64      `tfsdk:"inotify_max_user_instances"`
+	FreeDiskBytes           types.Int64      `tfsdk:"free_disk_bytes"`
+	Passed                  types.Bool       `tfsdk:"passed"`
+	Checks                  []hostCheckModel `tfsdk:"checks"`
+}
+
+type hostCheckModel struct {
+	Name    types.String `tfsdk:"name"`
+	Status  types.String `tfsdk:"status"`
+	Value   types.String `tfsdk:"value"`
+	Message types.String `tfsdk:"message"`
+}
+
+func newHostDataSource() datasource.DataSource {
+	return &hostDataSource{}
+}
+
+func (d *hostDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_host"
+}
+
+func (d *hostDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "The container runtime and host limits kind clusters depend on, checked like the provider's preflight option does. Failed checks don't fail the data source, use `passed` or `checks` in preconditions.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The container runtime.",
+				Computed:    true,
+			},
+			"runtime": schema.StringAttribute{
+				Description: "The container runtime kind uses, e.g. docker or podman.",
+				Computed:    true,
+			},
+			"runtime_version": schema.StringAttribute{
+				Description: "The version of the container runtime, null if it isn't reachable.",
+				Computed:    true,
+			},
+			"rootless": schema.BoolAttribute{
+				Description: "Whether the container runtime runs rootless.",
+				Computed:    true,
+			},
+			"cgroup_version": schema.StringAttribute{
+				Description: "The cgroup version of the host, 1 or 2.",
+				Computed:    true,
+			},
+			"data_root": schema.StringAttribute{
+				Description: "The directory the container runtime stores images and containers in.",
+				Computed:    true,
+			},
+			"inotify_max_user_watches": schema.Int64Attribute{
+				Description: "The fs.inotify.max_user_watches limit of the host, null on hosts other than Linux.",
+				Computed:    true,
+			},
+			"inotify_max_user_instances": schema.Int64Attribute{
+				Description: "The fs.inotify.max_user_instances limit of the host, null on hosts other than Linux.",
+				Computed:    true,
+			},
+			"free_disk_bytes": schema.Int64Attribute{
+				Description: "The free disk space in the data root of the container runtime, null if it can't be determined, e.g. when the runtime runs in a VM.",
+				Computed:    true,
+			},
+			"passed": schema.BoolAttribute{
+				Description: "Whether no check failed.",
+				Computed:    true,
+			},
+			"checks": schema.ListNestedAttribute{
+				Description: "The result of each preflight check.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "The name of the check: runtime, runtime_version, cgroup_version, rootless, inotify_max_user_watches, inotify_max_user_instances or free_disk.",
+							Computed:    true,
+						},
+						"status": schema.StringAttribute{
+							Description: "The result of the check: pass, warn, fail or skip.",
+							Computed:    true,
+						},
+						"value": schema.StringAttribute{
+							Description: "The value that was checked.",
+							Computed:    true,
+						},
+						"message": schema.StringAttribute{
+							Description: "What to do about a check that didn't pass.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *hostDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	resp.Diagnostics.Append(resp.State.Set(ctx, flattenHostInfo(inspectHost()))...)
+}
+
+func flattenHostInfo(info hostInfo) *hostModel {
+	data := &hostModel{
+		ID:                      types.StringValue(info.Runtime),
+		Runtime:                 types.StringValue(info.Runtime),
+		RuntimeVersion:          optionalString(info.RuntimeVersion),
+		Rootless:                types.BoolValue(info.Rootless),
+		CgroupVersion:           optionalString(info.CgroupVersion),
+		DataRoot:                optionalString(info.DataRoot),
+		InotifyMaxUserWatches:   optionalInt64(info.InotifyMaxUserWatches),
+		InotifyMaxUserInstances: optionalInt64(info.InotifyMaxUserInstances),
+		FreeDiskBytes:           optionalInt64(info.FreeDiskBytes),
+		Passed:                  types.BoolValue(true),
+		Checks:                  []hostCheckModel{},
+	}
+	for _, check := range info.Checks {
+		if check.Status == hostCheckFail {
+			data.Passed = types.BoolValue(false)
+		}
+		data.Checks = append(data.Checks, hostCheckModel{
+			Name:    types.StringValue(check.Name),
+			Status:  types.StringValue(check.Status),
+			Value:   types.StringValue(check.Value),
+			Message: types.StringValue(check.Message),
+		})
+	}
+	return data
+}
+
+// optionalString returns a null string for an empty value.
+func optionalString(v string) types.String {
+	if v == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(v)
+}
+
+// optionalInt64 returns a null number for a value that couldn't be
+// determined.
+func optionalInt64(v int64) types.Int64 {
+	if v < 0 {
+		return types.Int64Null()
+	}
+	return types.Int64Value(v)
+}
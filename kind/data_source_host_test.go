@@ -0,0 +1,81 @@
+package kind
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDataSourceHostRead(t *testing.T) {
+	ctx := context.Background()
+	ds := newHostDataSource()
+	schemaResp := &datasource.SchemaResponse{}
+	ds.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	resp := &datasource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+		},
+	}
+	ds.Read(ctx, datasource.ReadRequest{}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+
+	var data hostModel
+	if diags := resp.State.Get(ctx, &data); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if got := data.Runtime.ValueString(); got != containerRuntime() {
+		t.Errorf("expected runtime %q, got %q", containerRuntime(), got)
+	}
+	if len(data.Checks) == 0 {
+		t.Error("expected checks")
+	}
+}
+
+func TestFlattenHostInfo(t *testing.T) {
+	info := hostInfo{
+		Runtime:                 "podman",
+		RuntimeVersion:          "5.2.3",
+		Rootless:                true,
+		CgroupVersion:           "2",
+		DataRoot:                "/home/user/.local/share/containers/storage",
+		InotifyMaxUserWatches:   -1,
+		InotifyMaxUserInstances: 128,
+		FreeDiskBytes:           -1,
+	}
+	info.Checks = checkHost(info)
+
+	data := flattenHostInfo(info)
+	if !data.Passed.ValueBool() {
+		t.Errorf("expected checks with warnings to pass, got %v", data.Checks)
+	}
+	if !data.InotifyMaxUserWatches.IsNull() || !data.FreeDiskBytes.IsNull() {
+		t.Errorf("expected unknown numbers to be null, got %v and %v", data.InotifyMaxUserWatches, data.FreeDiskBytes)
+	}
+	if got := data.InotifyMaxUserInstances.ValueInt64(); got != 128 {
+		t.Errorf("expected inotify_max_user_instances 128, got %d", got)
+	}
+	if len(data.Checks) != len(info.Checks) {
+		t.Errorf("expected %d checks, got %d", len(info.Checks), len(data.Checks))
+	}
+
+	info.CgroupVersion = "1"
+	info.Checks = checkHost(info)
+	if flattenHostInfo(info).Passed.ValueBool() {
+		t.Error("expected a failed check to fail")
+	}
+
+	unreachable := hostInfo{Runtime: "docker", RuntimeError: errors.New("Cannot connect to the Docker daemon"), FreeDiskBytes: -1, InotifyMaxUserWatches: -1, InotifyMaxUserInstances: -1}
+	unreachable.Checks = checkHost(unreachable)
+	data = flattenHostInfo(unreachable)
+	if data.Passed.ValueBool() || !data.RuntimeVersion.IsNull() {
+		t.Errorf("expected an unreachable runtime to fail without a version, got %v", data)
+	}
+}
@@ -0,0 +1,116 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+	kindVersion "sigs.k8s.io/kind/pkg/cmd/kind/version"
+)
+
+// nodeImagesDataSource lists the node images published for the kind version
+// compiled into the provider.
+type nodeImagesDataSource struct{}
+
+var _ datasource.DataSource = &nodeImagesDataSource{}
+
+type nodeImagesModel struct {
+	ID                types.String     `tfsdk:"id"`
+	KubernetesVersion types.String     `tfsdk:"kubernetes_version"`
+	KindVersion       types.String     `tfsdk:"kind_version"`
+	DefaultImage      types.String     `tfsdk:"default_image"`
+	Images            []nodeImageModel `tfsdk:"images"`
+}
+
+type nodeImageModel struct {
+	KubernetesVersion types.String `tfsdk:"kubernetes_version"`
+	Image             types.String `tfsdk:"image"`
+	Digest            types.String `tfsdk:"digest"`
+}
+
+func newNodeImagesDataSource() datasource.DataSource {
+	return &nodeImagesDataSource{}
+}
+
+func (d *nodeImagesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_node_images"
+}
+
+func (d *nodeImagesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "The node images compatible with the kind version compiled into the provider.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The kind version.",
+				Computed:    true,
+			},
+			"kubernetes_version": schema.StringAttribute{
+				Description: "Only list the images of a Kubernetes minor version like 1.31 or a patch version like 1.31.2.",
+				Optional:    true,
+			},
+			"kind_version": schema.StringAttribute{
+				Description: "The version of the kind library compiled into the provider.",
+				Computed:    true,
+			},
+			"default_image": schema.StringAttribute{
+				Description: "The node image kind uses when no node_image is set.",
+				Computed:    true,
+			},
+			"images": schema.ListNestedAttribute{
+				Description: "The node images published for the kind version, newest Kubernetes version first, filtered by kubernetes_version if set.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"kubernetes_version": schema.StringAttribute{
+							Description: "The Kubernetes version of the node image, e.g. 1.31.2.",
+							Computed:    true,
+						},
+						"image": schema.StringAttribute{
+							Description: "The digest pinned node image reference.",
+							Computed:    true,
+						},
+						"digest": schema.StringAttribute{
+							Description: "The digest of the node image, e.g. sha256:....",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *nodeImagesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data nodeImagesModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	version := kindVersion.Version()
+	data.ID = types.StringValue(version)
+	data.KindVersion = types.StringValue(version)
+	data.DefaultImage = types.StringValue(kindDefaults.Image)
+	data.Images = []nodeImageModel{}
+	filter := data.KubernetesVersion.ValueString()
+	if filter != "" && !kubernetesVersionRegexp.MatchString(filter) {
+		resp.Diagnostics.AddAttributeError(path.Root("kubernetes_version"), "Invalid kubernetes_version",
+			fmt.Sprintf("Expected a Kubernetes version like 1.31 or 1.31.2, got %q.", filter))
+		return
+	}
+	for _, img := range supportedNodeImages() {
+		if filter != "" && !img.matches(filter) {
+			continue
+		}
+		data.Images = append(data.Images, nodeImageModel{
+			KubernetesVersion: types.StringValue(img.KubernetesVersion),
+			Image:             types.StringValue(img.Image),
+			Digest:            types.StringValue(img.Digest),
+		})
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,120 @@
+package kind
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+	kindVersion "sigs.k8s.io/kind/pkg/cmd/kind/version"
+)
+
+// readNodeImages reads the kind_node_images data source with an optional
+// kubernetes_version filter.
+func readNodeImages(t *testing.T, version string) (nodeImagesModel, diag.Diagnostics) {
+	ctx := context.Background()
+	ds := newNodeImagesDataSource()
+	schemaResp := &datasource.SchemaResponse{}
+	ds.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	values := map[string]tftypes.Value{}
+	for name, typ := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	if version != "" {
+		values["kubernetes_version"] = tftypes.NewValue(tftypes.String, version)
+	}
+
+	resp := &datasource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(objectType, nil),
+		},
+	}
+	ds.Read(ctx, datasource.ReadRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(objectType, values),
+		},
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		return nodeImagesModel{}, resp.Diagnostics
+	}
+
+	var data nodeImagesModel
+	if diags := resp.State.Get(ctx, &data); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	return data, nil
+}
+
+func TestDataSourceNodeImagesRead(t *testing.T) {
+	data, diags := readNodeImages(t, "")
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if got := data.KindVersion.ValueString(); got != kindVersion.Version() {
+		t.Errorf("expected kind_version %q, got %q", kindVersion.Version(), got)
+	}
+	if got := data.DefaultImage.ValueString(); got != kindDefaults.Image {
+		t.Errorf("expected default_image %q, got %q", kindDefaults.Image, got)
+	}
+
+	found := false
+	for _, img := range data.Images {
+		if img.KubernetesVersion.ValueString() == "" || img.Digest.ValueString() == "" {
+			t.Errorf("expected version and digest for %v", img)
+		}
+		if img.Image.ValueString() == kindDefaults.Image {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the default image in %v", data.Images)
+	}
+}
+
+func TestDataSourceNodeImagesRead_Filter(t *testing.T) {
+	withNodeImages(t, testNodeImages)
+
+	cases := []struct {
+		Version  string
+		Expected []string
+	}{
+		{"", []string{"1.36.1", "1.35.4", "1.34.7", "1.33.10", "1.31.12"}},
+		{"1.31", []string{"1.31.12"}},
+		{"v1.35", []string{"1.35.4"}},
+		{"1.33.10", []string{"1.33.10"}},
+		// 1.3 is not a prefix of 1.31.12 or 1.36.1 as a version
+		{"1.3", []string{}},
+		{"1.32", []string{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Version, func(t *testing.T) {
+			data, diags := readNodeImages(t, tc.Version)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			got := []string{}
+			for _, img := range data.Images {
+				got = append(got, img.KubernetesVersion.ValueString())
+			}
+			if len(got) != len(tc.Expected) {
+				t.Fatalf("expected %v, got %v", tc.Expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.Expected[i] {
+					t.Errorf("expected %v, got %v", tc.Expected, got)
+				}
+			}
+		})
+	}
+
+	if _, diags := readNodeImages(t, "latest"); !diags.HasError() {
+		t.Error("expected an error for an invalid kubernetes_version")
+	}
+}
@@ -0,0 +1,146 @@
+package kind
+
+import (
+	goerrors "errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// kindConfigRegistryMirrorPath is the path of the registry mirrors of a
+// kind_cluster.
+var kindConfigRegistryMirrorPath = cty.GetAttrPath("kind_config").IndexInt(0).GetAttr("registry_mirror")
+
+// runtimeUnreachableErrors are fragments of the errors returned when the
+// container runtime isn't installed, isn't running or can't be reached.
+var runtimeUnreachableErrors = []string{
+	"Cannot connect to the Docker daemon",
+	"Is the docker daemon running",
+	"error during connect",
+	"Cannot connect to Podman",
+	"unable to connect to Podman",
+	"cannot access containerd socket",
+	"executable file not found",
+}
+
+// hostPortInUseRegexp matches the errors of the container runtime for a host
+// port that is already bound, by another container or by a host process.
+var hostPortInUseRegexp = regexp.MustCompile(`Bind for \S*:(\d+) failed: port is already allocated|listen tcp[46]? \S*:(\d+): bind: address already in use`)
+
+// errorOutput returns the message of err including the output of the failed
+// command, kind only keeps the actual container runtime error in the output.
+func errorOutput(err error) string {
+	msg := err.Error()
+	var runErr *exec.RunError
+	if goerrors.As(err, &runErr) {
+		if out := strings.TrimSpace(string(runErr.Output)); out != "" && !strings.Contains(msg, out) {
+			msg += "\n\n" + out
+		}
+	}
+	return msg
+}
+
+// runtimeUnreachable reports whether err was caused by a container runtime
+// that isn't installed or running.
+func runtimeUnreachable(err error) bool {
+	out := errorOutput(err)
+	for _, fragment := range runtimeUnreachableErrors {
+		if strings.Contains(out, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostPortInUse returns the host port err failed to bind.
+func hostPortInUse(err error) (int, bool) {
+	match := hostPortInUseRegexp.FindStringSubmatch(errorOutput(err))
+	if match == nil {
+		return 0, false
+	}
+	port, convErr := strconv.Atoi(match[1] + match[2])
+	return port, convErr == nil
+}
+
+// errorDetail returns the detail of a diagnostic for err, with remediation
+// for container runtime failures that have a known cause.
+func errorDetail(err error) string {
+	detail := errorOutput(err)
+	if runtimeUnreachable(err) {
+		runtime := containerRuntime()
+		detail += fmt.Sprintf("\n\nThe container runtime %s could not be reached. Make sure it is installed and running and the user running Terraform may access it, `%s info` shows whether it is reachable.", runtime, runtime)
+	} else if port, ok := hostPortInUse(err); ok {
+		detail += fmt.Sprintf("\n\nHost port %d is already in use by another container or process. Stop whatever is listening on it or map a different host port.", port)
+	}
+	return detail
+}
+
+// errorDiagnostics returns an error diagnostic for err pointing at path, which
+// may be nil if no attribute is to blame.
+func errorDiagnostics(summary string, err error, path cty.Path) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       summary,
+		Detail:        errorDetail(err),
+		AttributePath: path,
+	}}
+}
+
+// hostPortErrorDiagnostics is errorDiagnostics for creating nodes of a
+// cluster, pointing at the kind_config attribute mapping the host port if the
+// nodes failed to bind one.
+func hostPortErrorDiagnostics(summary string, err error, config []interface{}) diag.Diagnostics {
+	var path cty.Path
+	if port, ok := hostPortInUse(err); ok {
+		path = hostPortAttributePath(config, port)
+	}
+	return errorDiagnostics(summary, err, path)
+}
+
+// warningDiagnostic returns a warning for a failure that doesn't fail the
+// operation, like cleaning up after a resource that is already gone.
+func warningDiagnostic(summary string, err error) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  summary,
+		Detail:   errorOutput(err),
+	}
+}
+
+// hostPortAttributePath returns the path of the kind_config attribute mapping
+// a host port, an extra_port_mappings entry or the ingress host port of an
+// ingress_ready node. It returns nil if no node maps the port.
+func hostPortAttributePath(config []interface{}, port int) cty.Path {
+	root := cty.GetAttrPath("kind_config").IndexInt(0).GetAttr("node")
+	for i, n := range kindConfigNodeList(config) {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		mappings, _ := mapKeyIfExists(node, "extra_port_mappings").([]interface{})
+		for j, m := range mappings {
+			data, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if int(flattenKindConfigExtraPortMappings(data).HostPort) == port {
+				return root.IndexInt(i).GetAttr("extra_port_mappings").IndexInt(j).GetAttr("host_port")
+			}
+		}
+		if ready, _ := mapKeyIfExists(node, "ingress_ready").(bool); ready {
+			httpPort, httpsPort := ingressHostPorts(node)
+			switch port {
+			case int(httpPort):
+				return root.IndexInt(i).GetAttr("ingress_http_host_port")
+			case int(httpsPort):
+				return root.IndexInt(i).GetAttr("ingress_https_host_port")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package kind
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	kindErrors "sigs.k8s.io/kind/pkg/errors"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+func TestErrorDiagnostics(t *testing.T) {
+	runErr := &exec.RunError{
+		Command: []string{"docker", "ps"},
+		Output:  []byte("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?\n"),
+		Inner:   errors.New("exit status 1"),
+	}
+
+	cases := []struct {
+		Name          string
+		Err           error
+		ExpectDetail  []string
+		ExpectNoMatch string
+	}{
+		{
+			Name:         "RuntimeUnreachable",
+			Err:          kindErrors.Wrap(runErr, "failed to list nodes"),
+			ExpectDetail: []string{"failed to list nodes", "Is the docker daemon running?", "info` shows whether it is reachable"},
+		},
+		{
+			Name:         "HostPortAllocated",
+			Err:          errors.New("failed to create node: docker: Error response from daemon: driver failed programming external connectivity on endpoint test-control-plane: Bind for 0.0.0.0:8080 failed: port is already allocated."),
+			ExpectDetail: []string{"Host port 8080 is already in use"},
+		},
+		{
+			Name:          "Other",
+			Err:           errors.New("something went wrong"),
+			ExpectDetail:  []string{"something went wrong"},
+			ExpectNoMatch: "\n\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			path := cty.GetAttrPath("name")
+			diags := errorDiagnostics("Unable to do it", tc.Err, path)
+			if len(diags) != 1 {
+				t.Fatalf("expected 1 diagnostic, got %d", len(diags))
+			}
+			d := diags[0]
+			if d.Severity != diag.Error || d.Summary != "Unable to do it" || !d.AttributePath.Equals(path) {
+				t.Errorf("unexpected diagnostic %#v", d)
+			}
+			for _, s := range tc.ExpectDetail {
+				if !strings.Contains(d.Detail, s) {
+					t.Errorf("expected detail to contain %q, got %q", s, d.Detail)
+				}
+			}
+			if tc.ExpectNoMatch != "" && strings.Contains(d.Detail, tc.ExpectNoMatch) {
+				t.Errorf("expected detail without remediation, got %q", d.Detail)
+			}
+		})
+	}
+}
+
+func TestHostPortInUse(t *testing.T) {
+	cases := []struct {
+		Message string
+		Port    int
+		InUse   bool
+	}{
+		{"Bind for 0.0.0.0:8080 failed: port is already allocated", 8080, true},
+		{"Error starting userland proxy: listen tcp4 127.0.0.1:443: bind: address already in use", 443, true},
+		{"rootlessport listen tcp 0.0.0.0:30000: bind: address already in use", 30000, true},
+		{"no such container", 0, false},
+	}
+	for _, tc := range cases {
+		port, inUse := hostPortInUse(errors.New(tc.Message))
+		if port != tc.Port || inUse != tc.InUse {
+			t.Errorf("%q: expected %d %t, got %d %t", tc.Message, tc.Port, tc.InUse, port, inUse)
+		}
+	}
+}
+
+func TestHostPortAttributePath(t *testing.T) {
+	config := []interface{}{
+		map[string]interface{}{
+			"node": []interface{}{
+				map[string]interface{}{
+					"role":                   "control-plane",
+					"ingress_ready":          true,
+					"ingress_http_host_port": 8080,
+				},
+				map[string]interface{}{
+					"role": "worker",
+					"extra_port_mappings": []interface{}{
+						map[string]interface{}{"container_port": 30000, "host_port": 30000},
+						map[string]interface{}{"container_port": 30001, "host_port": 30001},
+					},
+				},
+			},
+		},
+	}
+	node := cty.GetAttrPath("kind_config").IndexInt(0).GetAttr("node")
+
+	cases := []struct {
+		Port int
+		Path cty.Path
+	}{
+		{30001, node.IndexInt(1).GetAttr("extra_port_mappings").IndexInt(1).GetAttr("host_port")},
+		{8080, node.IndexInt(0).GetAttr("ingress_http_host_port")},
+		{443, node.IndexInt(0).GetAttr("ingress_https_host_port")},
+		{9999, nil},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprint(tc.Port), func(t *testing.T) {
+			diags := hostPortErrorDiagnostics("Unable to create", fmt.Errorf("Bind for 0.0.0.0:%d failed: port is already allocated", tc.Port), config)
+			if got := diags[0].AttributePath; !got.Equals(tc.Path) {
+				t.Errorf("expected path %#v, got %#v", tc.Path, got)
+			}
+		})
+	}
+}
+
+func TestRemoveKubeContext_MissingFile(t *testing.T) {
+	if err := removeKubeContext(filepath.Join(t.TempDir(), "config"), "kind-test", "test"); err != nil {
+		t.Errorf("expected no error for a missing kubeconfig, got %s", err)
+	}
+}
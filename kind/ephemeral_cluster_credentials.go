@@ -0,0 +1,101 @@
+package kind
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+)
+
+// clusterCredentialsEphemeralResource returns the credentials of a kind
+// cluster without persisting them to the plan or state.
+type clusterCredentialsEphemeralResource struct{}
+
+var _ ephemeral.EphemeralResource = &clusterCredentialsEphemeralResource{}
+
+type clusterCredentialsModel struct {
+	Name                 types.String `tfsdk:"name"`
+	Internal             types.Bool   `tfsdk:"internal"`
+	Kubeconfig           types.String `tfsdk:"kubeconfig"`
+	ClientCertificate    types.String `tfsdk:"client_certificate"`
+	ClientKey            types.String `tfsdk:"client_key"`
+	ClusterCACertificate types.String `tfsdk:"cluster_ca_certificate"`
+	Endpoint             types.String `tfsdk:"endpoint"`
+}
+
+func newClusterCredentialsEphemeralResource() ephemeral.EphemeralResource {
+	return &clusterCredentialsEphemeralResource{}
+}
+
+func (r *clusterCredentialsEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cluster_credentials"
+}
+
+func (r *clusterCredentialsEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "The kubeconfig and credentials of a kind cluster, not persisted to the plan or state.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Description: "The name of the kind cluster.",
+				Required:    true,
+			},
+			"internal": schema.BoolAttribute{
+				Description: "Use the address of the control plane on the container network instead of the host, e.g. to reach the cluster from other containers. Defaults to false.",
+				Optional:    true,
+			},
+			"kubeconfig": schema.StringAttribute{
+				Description: "The kubeconfig of the cluster.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"client_certificate": schema.StringAttribute{
+				Description: "Client certificate for authenticating to cluster.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"client_key": schema.StringAttribute{
+				Description: "Client key for authenticating to cluster.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"cluster_ca_certificate": schema.StringAttribute{
+				Description: "Client verifies the server certificate with this CA cert.",
+				Computed:    true,
+			},
+			"endpoint": schema.StringAttribute{
+				Description: "Kubernetes APIServer endpoint.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *clusterCredentialsEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data clusterCredentialsModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := data.Name.ValueString()
+	provider := newKindProvider(ctx)
+	kconfig, err := provider.KubeConfig(name, data.Internal.ValueBool())
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to get kubeconfig of kind cluster "+name, err.Error())
+		return
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kconfig))
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to parse kubeconfig of kind cluster "+name, err.Error())
+		return
+	}
+
+	data.Kubeconfig = types.StringValue(kconfig)
+	data.ClientCertificate = types.StringValue(string(config.CertData))
+	data.ClientKey = types.StringValue(string(config.KeyData))
+	data.ClusterCACertificate = types.StringValue(string(config.CAData))
+	data.Endpoint = types.StringValue(config.Host)
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
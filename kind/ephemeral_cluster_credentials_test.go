@@ -0,0 +1,48 @@
+package kind
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestResourceClusterCredentialsSensitive(t *testing.T) {
+	s := resourceCluster().Schema
+	for _, k := range []string{"kubeconfig", "client_certificate", "client_key"} {
+		if !s[k].Sensitive {
+			t.Errorf("expected %s to be sensitive", k)
+		}
+	}
+}
+
+func TestAccClusterCredentialsEphemeralResource(t *testing.T) {
+	clusterName := acctest.RandomWithPrefix("tf-acc-credentials-test")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterCredentialsConfig(clusterName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kind_cluster.test", "name", clusterName),
+				),
+			},
+		},
+	})
+}
+
+func testAccClusterCredentialsConfig(clusterName string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name = "%s"
+}
+
+ephemeral "kind_cluster_credentials" "test" {
+  name = kind_cluster.test.name
+}
+`, clusterName)
+}
@@ -0,0 +1,235 @@
+package kind
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/constants"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+const (
+	staticPodManifestsDir = "/etc/kubernetes/manifests"
+	etcdManifest          = "etcd.yaml"
+	apiServerManifest     = "kube-apiserver.yaml"
+
+	// etcdSnapshotPath is where snapshots are staged on the control plane
+	// node. It is below etcd's data directory mount so etcdctl running in the
+	// etcd container can write it.
+	etcdSnapshotPath = "/var/lib/etcd/terraform-provider-kind-snapshot.db"
+	// etcdRestorePath is where a snapshot to restore is staged on the node,
+	// outside of the data directory that gets replaced.
+	etcdRestorePath = "/var/lib/terraform-provider-kind-restore.db"
+
+	// staticPodStopTimeout bounds how long the kubelet may take to stop a
+	// static pod once its manifest was removed.
+	staticPodStopTimeout = time.Minute
+)
+
+// etcdControlPlaneNode returns the control plane node snapshots are taken on
+// and restored to.
+func etcdControlPlaneNode(provider *cluster.Provider, clusterName string) (nodes.Node, error) {
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	return nodeutils.BootstrapControlPlaneNode(allNodes)
+}
+
+// etcdContainerID returns the ID of the running etcd container on a control
+// plane node, or "" if etcd isn't running.
+func etcdContainerID(node nodes.Node) (string, error) {
+	lines, err := exec.OutputLines(node.Command("crictl", "ps", "--name", "^etcd$", "--state", "running", "-q"))
+	if err != nil {
+		return "", fmt.Errorf("failed to find etcd container on node %s: %s", node.String(), err)
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.TrimSpace(lines[0]), nil
+}
+
+// etcdctlArgs returns the etcdctl flags to talk to the local etcd member with
+// the certificates kubeadm generates.
+func etcdctlArgs(args ...string) []string {
+	return append([]string{
+		"etcdctl",
+		"--endpoints=https://127.0.0.1:2379",
+		"--cacert=/etc/kubernetes/pki/etcd/ca.crt",
+		"--cert=/etc/kubernetes/pki/etcd/server.crt",
+		"--key=/etc/kubernetes/pki/etcd/server.key",
+	}, args...)
+}
+
+// saveEtcdSnapshot takes an etcd snapshot on a control plane node and copies
+// it to hostPath.
+func saveEtcdSnapshot(node nodes.Node, hostPath string) error {
+	id, err := etcdContainerID(node)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("etcd is not running on node %s", node.String())
+	}
+
+	save := append([]string{"exec", id}, etcdctlArgs("snapshot", "save", etcdSnapshotPath)...)
+	if lines, err := exec.CombinedOutputLines(node.Command("crictl", save...)); err != nil {
+		return fmt.Errorf("failed to save etcd snapshot on node %s: %s: %v", node.String(), err, lines)
+	}
+	defer node.Command("rm", "-f", etcdSnapshotPath).Run()
+
+	if err := os.MkdirAll(filepath.Dir(hostPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for snapshot %q: %s", hostPath, err)
+	}
+	f, err := os.Create(hostPath)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot %q: %s", hostPath, err)
+	}
+	defer f.Close()
+	if err := node.Command("cat", etcdSnapshotPath).SetStdout(f).Run(); err != nil {
+		os.Remove(hostPath)
+		return fmt.Errorf("failed to copy etcd snapshot from node %s: %s", node.String(), err)
+	}
+	return nil
+}
+
+// restoreEtcdSnapshot replaces the etcd data of a single control plane
+// cluster with a snapshot from hostPath. etcd and the API server are stopped
+// while the data directory is swapped, the restore itself runs etcdutl from
+// the etcd image since the node image doesn't ship it.
+func restoreEtcdSnapshot(ctx context.Context, node nodes.Node, hostPath string, timeout time.Duration) error {
+	ctx = tflog.SetField(ctx, "node", node.String())
+	f, err := os.Open(hostPath)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot %q: %s", hostPath, err)
+	}
+	defer f.Close()
+	if err := node.Command("cp", "/dev/stdin", etcdRestorePath).SetStdin(f).Run(); err != nil {
+		return fmt.Errorf("failed to copy snapshot to node %s: %s", node.String(), err)
+	}
+	defer node.Command("rm", "-f", etcdRestorePath).Run()
+
+	var manifest bytes.Buffer
+	if err := node.Command("cat", path.Join(staticPodManifestsDir, etcdManifest)).SetStdout(&manifest).Run(); err != nil {
+		return fmt.Errorf("failed to read etcd manifest on node %s: %s", node.String(), err)
+	}
+	etcd := parseStaticPodManifest(manifest.String())
+	for _, key := range []string{"name", "data-dir", "initial-advertise-peer-urls"} {
+		if etcd.flags[key] == "" {
+			return fmt.Errorf("etcd manifest on node %s has no --%s flag", node.String(), key)
+		}
+	}
+	if etcd.image == "" {
+		return fmt.Errorf("etcd manifest on node %s has no image", node.String())
+	}
+
+	tflog.Info(ctx, "Stopping etcd and the API server")
+	for _, m := range []string{apiServerManifest, etcdManifest} {
+		if err := node.Command("mv", path.Join(staticPodManifestsDir, m), path.Join("/etc/kubernetes", m)).Run(); err != nil {
+			return fmt.Errorf("failed to stop static pod %s on node %s: %s", m, node.String(), err)
+		}
+	}
+	if err := waitForEtcdStopped(node, staticPodStopTimeout); err != nil {
+		return err
+	}
+
+	dataDir := etcd.flags["data-dir"]
+	restoreDir := dataDir + "-restore"
+	restore := []string{
+		"-n", "k8s.io", "run", "--rm", "--net-host",
+		"--mount", "type=bind,src=/var/lib,dst=/var/lib,options=rbind:rw",
+		etcd.image, "terraform-provider-kind-etcd-restore",
+		"etcdutl", "snapshot", "restore", etcdRestorePath,
+		"--data-dir", restoreDir,
+		"--name", etcd.flags["name"],
+		"--initial-cluster", fmt.Sprintf("%s=%s", etcd.flags["name"], etcd.flags["initial-advertise-peer-urls"]),
+		"--initial-advertise-peer-urls", etcd.flags["initial-advertise-peer-urls"],
+	}
+	node.Command("rm", "-rf", restoreDir).Run()
+	if lines, err := exec.CombinedOutputLines(node.Command("ctr", restore...)); err != nil {
+		return fmt.Errorf("failed to restore etcd snapshot on node %s: %s: %v", node.String(), err, lines)
+	}
+	swap := fmt.Sprintf("rm -rf %[1]s && mv %[2]s %[1]s", dataDir, restoreDir)
+	if err := node.Command("sh", "-c", swap).Run(); err != nil {
+		return fmt.Errorf("failed to replace etcd data on node %s: %s", node.String(), err)
+	}
+
+	tflog.Info(ctx, "Starting etcd and the API server")
+	for _, m := range []string{etcdManifest, apiServerManifest} {
+		if err := node.Command("mv", path.Join("/etc/kubernetes", m), path.Join(staticPodManifestsDir, m)).Run(); err != nil {
+			return fmt.Errorf("failed to start static pod %s on node %s: %s", m, node.String(), err)
+		}
+	}
+	return waitForAPIServer(ctx, node, timeout)
+}
+
+func waitForEtcdStopped(node nodes.Node, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		id, err := etcdContainerID(node)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("etcd on node %s did not stop within %s", node.String(), timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// staticPod holds what restoring etcd needs from its kubeadm static pod
+// manifest.
+type staticPod struct {
+	image string
+	flags map[string]string
+}
+
+// parseStaticPodManifest extracts the image and the --key=value command flags
+// of a kubeadm generated static pod manifest with a single container.
+func parseStaticPodManifest(manifest string) staticPod {
+	pod := staticPod{flags: map[string]string{}}
+	for _, line := range strings.Split(manifest, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "image:") && pod.image == "" {
+			pod.image = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "image:")), `"'`)
+			continue
+		}
+		if !strings.HasPrefix(line, "- --") {
+			continue
+		}
+		if k, v, ok := strings.Cut(strings.TrimPrefix(line, "- --"), "="); ok {
+			pod.flags[k] = v
+		}
+	}
+	return pod
+}
+
+// singleControlPlane returns an error unless a cluster has exactly one
+// control plane node, restoring a multi member etcd isn't supported.
+func singleControlPlane(provider *cluster.Provider, clusterName string) error {
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	controlPlanes, err := nodeutils.SelectNodesByRole(allNodes, constants.ControlPlaneNodeRoleValue)
+	if err != nil {
+		return err
+	}
+	if len(controlPlanes) != 1 {
+		return fmt.Errorf("restoring an etcd snapshot requires a single control plane node, cluster %q has %d", clusterName, len(controlPlanes))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package kind
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStaticPodManifest(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: etcd
+  namespace: kube-system
+spec:
+  containers:
+  - command:
+    - etcd
+    - --advertise-client-urls=https://172.18.0.2:2379
+    - --data-dir=/var/lib/etcd
+    - --initial-advertise-peer-urls=https://172.18.0.2:2380
+    - --initial-cluster=test-control-plane=https://172.18.0.2:2380
+    - --name=test-control-plane
+    image: registry.k8s.io/etcd:3.5.12-0
+    imagePullPolicy: IfNotPresent
+`
+	pod := parseStaticPodManifest(manifest)
+	if pod.image != "registry.k8s.io/etcd:3.5.12-0" {
+		t.Errorf("unexpected image %q", pod.image)
+	}
+	expected := map[string]string{
+		"advertise-client-urls":       "https://172.18.0.2:2379",
+		"data-dir":                    "/var/lib/etcd",
+		"initial-advertise-peer-urls": "https://172.18.0.2:2380",
+		"initial-cluster":             "test-control-plane=https://172.18.0.2:2380",
+		"name":                        "test-control-plane",
+	}
+	if !reflect.DeepEqual(pod.flags, expected) {
+		t.Errorf("expected flags %v, got %v", expected, pod.flags)
+	}
+}
+
+func TestEtcdctlArgs(t *testing.T) {
+	args := etcdctlArgs("snapshot", "save", "/tmp/snapshot.db")
+	if args[0] != "etcdctl" {
+		t.Errorf("expected etcdctl, got %q", args[0])
+	}
+	if tail := args[len(args)-3:]; !reflect.DeepEqual(tail, []string{"snapshot", "save", "/tmp/snapshot.db"}) {
+		t.Errorf("expected subcommand at the end, got %v", tail)
+	}
+}
@@ -0,0 +1,102 @@
+package kind
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// frameworkProvider serves the resources and data sources built with
+// terraform-plugin-framework next to the terraform-plugin-sdk provider
+// returned by Provider. Both are combined by ProviderServer, so the provider
+// schema has to match Provider's. New resources and data sources are added
+// here, existing ones stay with Provider to keep their state compatible.
+type frameworkProvider struct{}
+
+var (
+	_ provider.ProviderWithEphemeralResources = &frameworkProvider{}
+	_ provider.ProviderWithFunctions          = &frameworkProvider{}
+)
+
+// frameworkProviderModel maps the provider block.
+type frameworkProviderModel struct {
+	RequireImageDigest types.Bool  `tfsdk:"require_image_digest"`
+	Preflight          types.Bool  `tfsdk:"preflight"`
+	MaxParallelCreates types.Int64 `tfsdk:"max_parallel_creates"`
+}
+
+func newFrameworkProvider() provider.Provider {
+	return &frameworkProvider{}
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "kind"
+}
+
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"require_image_digest": schema.BoolAttribute{
+				Description: "Reject node images that aren't pinned to a digest (image@sha256:...) or an image ID (sha256:...). Defaults to false, which only warns.",
+				Optional:    true,
+			},
+			"preflight": schema.BoolAttribute{
+				Description: "Check the container runtime and host limits when the provider is configured and fail before creating anything if a check fails. The checks are the ones reported by the kind_host data source. Defaults to false.",
+				Optional:    true,
+			},
+			"max_parallel_creates": schema.Int64Attribute{
+				Description: "The maximum number of kind_cluster resources created at the same time, the others wait for a slot. Defaults to 0, which doesn't limit it. Independent of it, clusters are created one at a time until the shared kind network exists.",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var data frameworkProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// preflight checks are run by Provider, running them here as well would
+	// report every failure twice. Clusters are created by Provider as well, so
+	// max_parallel_creates doesn't need a semaphore here.
+	config := &providerConfig{
+		RequireImageDigest: data.RequireImageDigest.ValueBool(),
+	}
+	resp.DataSourceData = config
+	resp.ResourceData = config
+	resp.EphemeralResourceData = config
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return nil
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		newHostDataSource,
+		newNodeImagesDataSource,
+	}
+}
+
+func (p *frameworkProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		newClusterCredentialsEphemeralResource,
+	}
+}
+
+func (p *frameworkProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		newConfigYAMLFunction,
+		newKubeconfigContextFunction,
+		newNodeImageFunction,
+	}
+}
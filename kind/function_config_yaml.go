@@ -0,0 +1,238 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+	"sigs.k8s.io/yaml"
+)
+
+// configYAMLFunction renders a kind_config shaped object as kind v1alpha4
+// cluster configuration.
+type configYAMLFunction struct{}
+
+var _ function.Function = &configYAMLFunction{}
+
+func newConfigYAMLFunction() function.Function {
+	return &configYAMLFunction{}
+}
+
+func (f *configYAMLFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "config_yaml"
+}
+
+func (f *configYAMLFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Renders a kind cluster configuration as YAML",
+		Description: "Renders an object with the attributes of the kind_config block of kind_cluster, e.g. node, networking and containerd_config_patches, as kind.x-k8s.io/v1alpha4 Cluster YAML for the kind CLI. kind and api_version default to Cluster and kind.x-k8s.io/v1alpha4, nested blocks may be given as objects or lists of objects.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:        "config",
+				Description: "The kind_config object.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *configYAMLFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var config types.Dynamic
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &config))
+	if resp.Error != nil {
+		return
+	}
+
+	raw, err := attrValueToInterface(config)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	data, ok := raw.(map[string]interface{})
+	if !ok {
+		resp.Error = function.NewArgumentFuncError(0, "config must be an object")
+		return
+	}
+	cluster, err := expandKindConfigObject(ctx, data)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	out, err := yaml.Marshal(cluster)
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("failed to render kind config: %s", err))
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, string(out)))
+}
+
+// expandKindConfigObject validates an object against the kind_config schema,
+// applies its defaults and flattens it like kind_cluster does.
+func expandKindConfigObject(ctx context.Context, data map[string]interface{}) (*v1alpha4.Cluster, error) {
+	if _, ok := data["registry_mirror"]; ok {
+		return nil, fmt.Errorf("registry_mirror is configured on the nodes by kind_cluster and can't be rendered, use containerd_config_patches instead")
+	}
+	fields := kindConfigFields()
+	data = normalizeConfigObject(data, fields)
+	if _, ok := data["kind"]; !ok {
+		data["kind"] = "Cluster"
+	}
+	if _, ok := data["api_version"]; !ok {
+		data["api_version"] = "kind.x-k8s.io/v1alpha4"
+	}
+
+	c := terraform.NewResourceConfigRaw(data)
+	sm := schema.InternalMap(fields)
+	if diags := sm.Validate(c); diags.HasError() {
+		errs := []string{}
+		for _, d := range diags {
+			if d.Detail != "" {
+				errs = append(errs, d.Summary+": "+d.Detail)
+			} else {
+				errs = append(errs, d.Summary)
+			}
+		}
+		return nil, fmt.Errorf("invalid kind config: %s", strings.Join(errs, "; "))
+	}
+	diff, err := sm.Diff(ctx, nil, c, nil, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	d, err := sm.Data(nil, diff)
+	if err != nil {
+		return nil, err
+	}
+
+	flattened := map[string]interface{}{}
+	for k := range fields {
+		flattened[k] = d.Get(k)
+	}
+	return flattenKindConfig(flattened), nil
+}
+
+// normalizeConfigObject brings an object converted from HCL into the shape of
+// a raw config for the given schema: single nested blocks given as objects
+// are wrapped in lists, map values are converted to strings and whole numbers
+// to ints.
+func normalizeConfigObject(data map[string]interface{}, fields map[string]*schema.Schema) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range data {
+		if v == nil {
+			continue
+		}
+		s, ok := fields[k]
+		if !ok {
+			// left for validation to report
+			out[k] = v
+			continue
+		}
+		switch s.Type {
+		case schema.TypeList, schema.TypeSet:
+			if m, ok := v.(map[string]interface{}); ok {
+				v = []interface{}{m}
+			}
+			if r, ok := s.Elem.(*schema.Resource); ok {
+				if items, ok := v.([]interface{}); ok {
+					for i, item := range items {
+						if m, ok := item.(map[string]interface{}); ok {
+							items[i] = normalizeConfigObject(m, r.Schema)
+						}
+					}
+				}
+			}
+		case schema.TypeMap:
+			if m, ok := v.(map[string]interface{}); ok {
+				for mk, mv := range m {
+					if mv != nil {
+						m[mk] = fmt.Sprint(mv)
+					}
+				}
+			}
+		case schema.TypeInt:
+			if f, ok := v.(float64); ok && f == float64(int(f)) {
+				v = int(f)
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// attrValueToInterface converts a framework value into the plain Go values
+// used by terraform.NewResourceConfigRaw.
+func attrValueToInterface(v attr.Value) (interface{}, error) {
+	if v == nil || v.IsNull() {
+		return nil, nil
+	}
+	if v.IsUnknown() {
+		return nil, fmt.Errorf("value is not known yet")
+	}
+
+	switch t := v.(type) {
+	case basetypes.DynamicValue:
+		return attrValueToInterface(t.UnderlyingValue())
+	case basetypes.StringValue:
+		return t.ValueString(), nil
+	case basetypes.BoolValue:
+		return t.ValueBool(), nil
+	case basetypes.NumberValue:
+		f := t.ValueBigFloat()
+		if f.IsInt() {
+			i, acc := f.Int64()
+			if acc == big.Exact {
+				return int(i), nil
+			}
+		}
+		n, _ := f.Float64()
+		return n, nil
+	case basetypes.Int64Value:
+		return int(t.ValueInt64()), nil
+	case basetypes.Float64Value:
+		return t.ValueFloat64(), nil
+	case basetypes.ListValue:
+		return attrValuesToInterface(t.Elements())
+	case basetypes.TupleValue:
+		return attrValuesToInterface(t.Elements())
+	case basetypes.SetValue:
+		return attrValuesToInterface(t.Elements())
+	case basetypes.MapValue:
+		return attrValueMapToInterface(t.Elements())
+	case basetypes.ObjectValue:
+		return attrValueMapToInterface(t.Attributes())
+	}
+	return nil, fmt.Errorf("unsupported value %s", v)
+}
+
+func attrValuesToInterface(values []attr.Value) (interface{}, error) {
+	out := make([]interface{}, 0, len(values))
+	for _, e := range values {
+		i, err := attrValueToInterface(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, i)
+	}
+	return out, nil
+}
+
+func attrValueMapToInterface(values map[string]attr.Value) (interface{}, error) {
+	out := make(map[string]interface{}, len(values))
+	for k, e := range values {
+		i, err := attrValueToInterface(e)
+		if err != nil {
+			return nil, err
+		}
+		if i != nil {
+			out[k] = i
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package kind
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testObject builds an object value with types inferred from the values.
+func testObject(t *testing.T, attrs map[string]attr.Value) types.Object {
+	attrTypes := map[string]attr.Type{}
+	for k, v := range attrs {
+		attrTypes[k] = v.Type(nil)
+	}
+	obj, diags := types.ObjectValue(attrTypes, attrs)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	return obj
+}
+
+func testTuple(t *testing.T, elems ...attr.Value) types.Tuple {
+	elemTypes := []attr.Type{}
+	for _, e := range elems {
+		elemTypes = append(elemTypes, e.Type(nil))
+	}
+	tuple, diags := types.TupleValue(elemTypes, elems)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	return tuple
+}
+
+func TestConfigYAMLFunction(t *testing.T) {
+	config := testObject(t, map[string]attr.Value{
+		"node": testTuple(t,
+			testObject(t, map[string]attr.Value{
+				"role":          types.StringValue("control-plane"),
+				"ingress_ready": types.BoolValue(true),
+			}),
+			testObject(t, map[string]attr.Value{
+				"role": types.StringValue("worker"),
+				"extra_port_mappings": testTuple(t, testObject(t, map[string]attr.Value{
+					"container_port": types.NumberValue(bigFloat(30080)),
+					"host_port":      types.NumberValue(bigFloat(8080)),
+				})),
+			}),
+		),
+		"networking": testObject(t, map[string]attr.Value{
+			"pod_subnet": types.StringValue("10.240.0.0/16"),
+		}),
+		"feature_gates": testObject(t, map[string]attr.Value{
+			"SomeFeature": types.BoolValue(true),
+		}),
+	})
+
+	resp := testRunFunction(newConfigYAMLFunction(), types.StringUnknown(), types.DynamicValue(config))
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	out := resp.Result.Value().(types.String).ValueString()
+	for _, expected := range []string{
+		"kind: Cluster",
+		"apiVersion: kind.x-k8s.io/v1alpha4",
+		"role: control-plane",
+		"ingress-ready: \"true\"",
+		"hostPort: 80",
+		"containerPort: 30080",
+		"hostPort: 8080",
+		"podSubnet: 10.240.0.0/16",
+		"SomeFeature: true",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in:\n%s", expected, out)
+		}
+	}
+}
+
+func TestConfigYAMLFunctionInvalid(t *testing.T) {
+	cases := map[string]attr.Value{
+		"UnknownAttribute": testObject(t, map[string]attr.Value{"nodes": types.StringValue("x")}),
+		"RegistryMirror": testObject(t, map[string]attr.Value{
+			"registry_mirror": testTuple(t, testObject(t, map[string]attr.Value{"registry": types.StringValue("docker.io")})),
+		}),
+		"NotAnObject": types.StringValue("kind: Cluster"),
+	}
+	for name, config := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := testRunFunction(newConfigYAMLFunction(), types.StringUnknown(), types.DynamicValue(config))
+			if resp.Error == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func bigFloat(f float64) *big.Float {
+	return big.NewFloat(f)
+}
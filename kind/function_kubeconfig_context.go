@@ -0,0 +1,133 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeconfigContextAttributeTypes are the attributes returned for a context,
+// named like the matching kind_cluster attributes.
+var kubeconfigContextAttributeTypes = map[string]attr.Type{
+	"name":                   types.StringType,
+	"cluster":                types.StringType,
+	"user":                   types.StringType,
+	"namespace":              types.StringType,
+	"endpoint":               types.StringType,
+	"cluster_ca_certificate": types.StringType,
+	"client_certificate":     types.StringType,
+	"client_key":             types.StringType,
+}
+
+// kubeconfigContextFunction extracts the cluster and credentials of a context
+// from a kubeconfig.
+type kubeconfigContextFunction struct{}
+
+var _ function.Function = &kubeconfigContextFunction{}
+
+func newKubeconfigContextFunction() function.Function {
+	return &kubeconfigContextFunction{}
+}
+
+func (f *kubeconfigContextFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "kubeconfig_context"
+}
+
+func (f *kubeconfigContextFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Returns the cluster and credentials of a kubeconfig context",
+		Description: "Parses a kubeconfig and returns the endpoint, CA certificate and client credentials of a context. The name is a context name, a kind cluster name, which is looked up as kind-<name>, or empty for the current context.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "kubeconfig",
+				Description: "The kubeconfig, e.g. the kubeconfig attribute of kind_cluster.",
+			},
+			function.StringParameter{
+				Name:        "name",
+				Description: "The context or kind cluster name, empty for the current context.",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: kubeconfigContextAttributeTypes,
+		},
+	}
+}
+
+func (f *kubeconfigContextFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var kubeconfig, name string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &kubeconfig, &name))
+	if resp.Error != nil {
+		return
+	}
+
+	values, err := kubeconfigContext(kubeconfig, name)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	attrs := map[string]attr.Value{}
+	for k, v := range values {
+		attrs[k] = types.StringValue(v)
+	}
+	result, diags := types.ObjectValue(kubeconfigContextAttributeTypes, attrs)
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, result))
+}
+
+// kubeconfigContext returns the kubeconfigContextAttributeTypes values of a
+// context.
+func kubeconfigContext(kubeconfig, name string) (map[string]string, error) {
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %s", err)
+	}
+
+	if name == "" {
+		name = config.CurrentContext
+	}
+	kctx, ok := config.Contexts[name]
+	if !ok {
+		// kind names the contexts of its clusters kind-<cluster>
+		if kctx, ok = config.Contexts["kind-"+name]; ok {
+			name = "kind-" + name
+		}
+	}
+	if !ok {
+		names := make([]string, 0, len(config.Contexts))
+		for n := range config.Contexts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("context %q not found in kubeconfig, available contexts: %s", name, strings.Join(names, ", "))
+	}
+
+	values := map[string]string{
+		"name":      name,
+		"cluster":   kctx.Cluster,
+		"user":      kctx.AuthInfo,
+		"namespace": kctx.Namespace,
+	}
+	if cluster, ok := config.Clusters[kctx.Cluster]; ok {
+		values["endpoint"] = cluster.Server
+		values["cluster_ca_certificate"] = string(cluster.CertificateAuthorityData)
+	}
+	if user, ok := config.AuthInfos[kctx.AuthInfo]; ok {
+		values["client_certificate"] = string(user.ClientCertificateData)
+		values["client_key"] = string(user.ClientKeyData)
+	}
+	for k := range kubeconfigContextAttributeTypes {
+		if _, ok := values[k]; !ok {
+			values[k] = ""
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,76 @@
+package kind
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func testKubeconfig(t *testing.T) string {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["kind-test"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443", CertificateAuthorityData: []byte("ca")}
+	config.AuthInfos["kind-test"] = &clientcmdapi.AuthInfo{ClientCertificateData: []byte("cert"), ClientKeyData: []byte("key")}
+	config.Contexts["kind-test"] = &clientcmdapi.Context{Cluster: "kind-test", AuthInfo: "kind-test"}
+	config.Contexts["other"] = &clientcmdapi.Context{Cluster: "other", AuthInfo: "other", Namespace: "default"}
+	config.CurrentContext = "kind-test"
+	out, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestKubeconfigContext(t *testing.T) {
+	kubeconfig := testKubeconfig(t)
+
+	for _, name := range []string{"", "kind-test", "test"} {
+		values, err := kubeconfigContext(kubeconfig, name)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %s", name, err)
+		}
+		expected := map[string]string{
+			"name":                   "kind-test",
+			"cluster":                "kind-test",
+			"user":                   "kind-test",
+			"namespace":              "",
+			"endpoint":               "https://127.0.0.1:6443",
+			"cluster_ca_certificate": "ca",
+			"client_certificate":     "cert",
+			"client_key":             "key",
+		}
+		for k, v := range expected {
+			if values[k] != v {
+				t.Errorf("expected %s %q for %q, got %q", k, v, name, values[k])
+			}
+		}
+	}
+
+	values, err := kubeconfigContext(kubeconfig, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["namespace"] != "default" || values["endpoint"] != "" {
+		t.Errorf("unexpected values for context without cluster: %v", values)
+	}
+
+	if _, err := kubeconfigContext(kubeconfig, "missing"); err == nil {
+		t.Error("expected an error for a missing context")
+	}
+	if _, err := kubeconfigContext("{", ""); err == nil {
+		t.Error("expected an error for an invalid kubeconfig")
+	}
+}
+
+func TestKubeconfigContextFunction(t *testing.T) {
+	resp := testRunFunction(newKubeconfigContextFunction(), types.ObjectUnknown(kubeconfigContextAttributeTypes),
+		types.StringValue(testKubeconfig(t)), types.StringValue("test"))
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	result := resp.Result.Value().(types.Object).Attributes()
+	if got := result["endpoint"].(types.String).ValueString(); got != "https://127.0.0.1:6443" {
+		t.Errorf("expected endpoint https://127.0.0.1:6443, got %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package kind
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// nodeImageFunction resolves a Kubernetes version to its node image, the same
+// way kubernetes_version of kind_cluster does.
+type nodeImageFunction struct{}
+
+var _ function.Function = &nodeImageFunction{}
+
+func newNodeImageFunction() function.Function {
+	return &nodeImageFunction{}
+}
+
+func (f *nodeImageFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "node_image"
+}
+
+func (f *nodeImageFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Returns the node image of a Kubernetes version",
+		Description: "Returns the digest pinned kindest/node image published for a Kubernetes version, e.g. 1.31 or 1.31.2, by the kind version compiled into the provider. A minor version resolves to its newest published patch version.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "version",
+				Description: "The Kubernetes version, e.g. 1.31 or 1.31.2.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *nodeImageFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var version string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &version))
+	if resp.Error != nil {
+		return
+	}
+
+	image, err := resolveKubernetesVersion(version)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, image))
+}
@@ -0,0 +1,62 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+)
+
+// testRunFunction runs a provider function with the given arguments.
+func testRunFunction(f function.Function, result attr.Value, args ...attr.Value) *function.RunResponse {
+	resp := &function.RunResponse{Result: function.NewResultData(result)}
+	f.Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData(args)}, resp)
+	return resp
+}
+
+func TestNodeImageFunction(t *testing.T) {
+	def, err := parseNodeImage(kindDefaults.Image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := testRunFunction(newNodeImageFunction(), types.StringUnknown(), types.StringValue(def.KubernetesVersion))
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	if got := resp.Result.Value().(types.String).ValueString(); got != kindDefaults.Image {
+		t.Errorf("expected %q, got %q", kindDefaults.Image, got)
+	}
+
+	resp = testRunFunction(newNodeImageFunction(), types.StringUnknown(), types.StringValue("1.1"))
+	if resp.Error == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
+
+func TestAccNodeImageFunction(t *testing.T) {
+	def, err := parseNodeImage(kindDefaults.Image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+output "node_image" {
+  value = provider::kind::node_image(%q)
+}
+`, def.KubernetesVersion),
+				Check: resource.TestCheckOutput("node_image", kindDefaults.Image),
+			},
+		},
+	})
+}
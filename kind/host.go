@@ -0,0 +1,260 @@
+package kind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// Results of a hostCheck.
+const (
+	hostCheckPass = "pass"
+	hostCheckWarn = "warn"
+	hostCheckFail = "fail"
+	hostCheckSkip = "skip"
+)
+
+// Limits checked by checkHost. The inotify limits are the ones kind's known
+// issues recommend for running several clusters.
+const (
+	minInotifyMaxUserWatches   = 524288
+	minInotifyMaxUserInstances = 512
+	minFreeDiskBytes           = 2 << 30
+	lowFreeDiskBytes           = 10 << 30
+)
+
+// minRuntimeVersions are the oldest container runtime versions kind supports.
+var minRuntimeVersions = map[string]string{
+	"docker": "20.10.0",
+	"podman": "3.0.0",
+}
+
+// hostInfo is what the provider knows about the host clusters are created
+// on. Numbers that couldn't be determined are -1.
+type hostInfo struct {
+	Runtime                 string
+	RuntimeVersion          string
+	RuntimeError            error
+	Rootless                bool
+	CgroupVersion           string
+	DataRoot                string
+	InotifyMaxUserWatches   int64
+	InotifyMaxUserInstances int64
+	FreeDiskBytes           int64
+	Checks                  []hostCheck
+}
+
+// hostCheck is the result of one preflight check.
+type hostCheck struct {
+	Name    string
+	Status  string
+	Value   string
+	Message string
+}
+
+// runtimeInfo holds the fields of `docker info` and `podman info` the
+// preflight checks need, the two use different layouts.
+type runtimeInfo struct {
+	ServerVersion   string   `json:"ServerVersion"`
+	CgroupVersion   string   `json:"CgroupVersion"`
+	SecurityOptions []string `json:"SecurityOptions"`
+	DockerRootDir   string   `json:"DockerRootDir"`
+
+	Host struct {
+		CgroupVersion string `json:"cgroupVersion"`
+		Security      struct {
+			Rootless bool `json:"rootless"`
+		} `json:"security"`
+	} `json:"host"`
+	Store struct {
+		GraphRoot string `json:"graphRoot"`
+	} `json:"store"`
+	Version struct {
+		Version string `json:"Version"`
+	} `json:"version"`
+}
+
+// inspectHost collects information about the container runtime and the host
+// and runs the preflight checks on it.
+func inspectHost() hostInfo {
+	info := hostInfo{
+		Runtime:                 containerRuntime(),
+		InotifyMaxUserWatches:   readSysctl("fs/inotify/max_user_watches"),
+		InotifyMaxUserInstances: readSysctl("fs/inotify/max_user_instances"),
+		FreeDiskBytes:           -1,
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(info.Runtime, "info", "--format", "{{json .}}").SetStdout(&stdout).SetStderr(&stderr)
+	if err := cmd.Run(); err != nil {
+		info.RuntimeError = fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	} else if err := parseRuntimeInfo(&info, stdout.Bytes()); err != nil {
+		info.RuntimeError = err
+	}
+
+	if info.DataRoot != "" {
+		// the data root is only present on the host if the runtime doesn't
+		// run in a VM, like Docker Desktop does
+		if free, err := freeDiskBytes(info.DataRoot); err == nil {
+			info.FreeDiskBytes = int64(free)
+		}
+	}
+
+	info.Checks = checkHost(info)
+	return info
+}
+
+// parseRuntimeInfo fills info from the JSON output of `<runtime> info`.
+func parseRuntimeInfo(info *hostInfo, data []byte) error {
+	var ri runtimeInfo
+	if err := json.Unmarshal(data, &ri); err != nil {
+		return fmt.Errorf("failed to parse %s info: %s", info.Runtime, err)
+	}
+
+	info.RuntimeVersion = ri.ServerVersion
+	info.CgroupVersion = ri.CgroupVersion
+	info.DataRoot = ri.DockerRootDir
+	for _, opt := range ri.SecurityOptions {
+		if opt == "name=rootless" {
+			info.Rootless = true
+		}
+	}
+	if ri.Version.Version != "" {
+		info.RuntimeVersion = ri.Version.Version
+		info.CgroupVersion = ri.Host.CgroupVersion
+		info.Rootless = ri.Host.Security.Rootless
+		info.DataRoot = ri.Store.GraphRoot
+	}
+	info.CgroupVersion = strings.TrimPrefix(info.CgroupVersion, "v")
+	return nil
+}
+
+// readSysctl reads a numeric kernel parameter, returning -1 if it isn't
+// available, e.g. on hosts other than Linux.
+func readSysctl(name string) int64 {
+	data, err := os.ReadFile("/proc/sys/" + name)
+	if err != nil {
+		return -1
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return v
+}
+
+// checkHost runs the preflight checks on the collected host information.
+func checkHost(info hostInfo) []hostCheck {
+	if info.RuntimeError != nil {
+		return []hostCheck{{
+			Name:    "runtime",
+			Status:  hostCheckFail,
+			Value:   info.Runtime,
+			Message: errorDetail(info.RuntimeError),
+		}}
+	}
+
+	checks := []hostCheck{{Name: "runtime", Status: hostCheckPass, Value: info.Runtime}}
+
+	version := hostCheck{Name: "runtime_version", Status: hostCheckPass, Value: info.RuntimeVersion}
+	if min, ok := minRuntimeVersions[info.Runtime]; ok && compareVersions(numericVersion(info.RuntimeVersion), min) < 0 {
+		version.Status = hostCheckFail
+		version.Message = fmt.Sprintf("%s %s is older than %s, the oldest version kind supports. Upgrade %s.", info.Runtime, info.RuntimeVersion, min, info.Runtime)
+	}
+	checks = append(checks, version)
+
+	cgroup := hostCheck{Name: "cgroup_version", Status: hostCheckPass, Value: info.CgroupVersion}
+	switch info.CgroupVersion {
+	case "":
+		cgroup.Status = hostCheckSkip
+		cgroup.Message = fmt.Sprintf("%s doesn't report the cgroup version of the host.", info.Runtime)
+	case "1":
+		cgroup.Status = hostCheckFail
+		cgroup.Message = "The host uses cgroup v1, recent Kubernetes versions don't start on cgroup v1 hosts. Boot the host with cgroup v2 (systemd.unified_cgroup_hierarchy=1)."
+	}
+	checks = append(checks, cgroup)
+
+	rootless := hostCheck{Name: "rootless", Status: hostCheckPass, Value: strconv.FormatBool(info.Rootless)}
+	if info.Rootless {
+		rootless.Message = "The container runtime runs rootless, kind needs cgroup v2 with the cpu, memory and pids controllers delegated to the user, see https://kind.sigs.k8s.io/docs/user/rootless/."
+		if info.CgroupVersion == "1" {
+			rootless.Status = hostCheckFail
+		}
+	}
+	checks = append(checks, rootless)
+
+	checks = append(checks,
+		checkMinimum("inotify_max_user_watches", info.InotifyMaxUserWatches, minInotifyMaxUserWatches, "fs.inotify.max_user_watches"),
+		checkMinimum("inotify_max_user_instances", info.InotifyMaxUserInstances, minInotifyMaxUserInstances, "fs.inotify.max_user_instances"),
+	)
+
+	disk := hostCheck{Name: "free_disk", Status: hostCheckPass, Value: strconv.FormatInt(info.FreeDiskBytes, 10)}
+	switch {
+	case info.FreeDiskBytes < 0:
+		disk.Status = hostCheckSkip
+		disk.Value = ""
+		disk.Message = fmt.Sprintf("The free disk space of the %s data root could not be determined, it may be inside a VM.", info.Runtime)
+	case info.FreeDiskBytes < minFreeDiskBytes:
+		disk.Status = hostCheckFail
+		disk.Message = fmt.Sprintf("Only %d MiB are free in %s, node images alone need about 1 GiB. Free up disk space, e.g. with `%s system prune`.", info.FreeDiskBytes>>20, info.DataRoot, info.Runtime)
+	case info.FreeDiskBytes < lowFreeDiskBytes:
+		disk.Status = hostCheckWarn
+		disk.Message = fmt.Sprintf("Only %d MiB are free in %s, clusters may run out of disk space and evict pods.", info.FreeDiskBytes>>20, info.DataRoot)
+	}
+	checks = append(checks, disk)
+
+	return checks
+}
+
+// checkMinimum warns about a kernel parameter below the recommended minimum.
+func checkMinimum(name string, value, minimum int64, sysctl string) hostCheck {
+	check := hostCheck{Name: name, Status: hostCheckPass, Value: strconv.FormatInt(value, 10)}
+	switch {
+	case value < 0:
+		check.Status = hostCheckSkip
+		check.Value = ""
+		check.Message = fmt.Sprintf("%s is only checked on Linux hosts.", sysctl)
+	case value < minimum:
+		check.Status = hostCheckWarn
+		check.Message = fmt.Sprintf("%s is %d, below the %d recommended for kind, pods may fail with \"too many open files\". Raise it with `sysctl %s=%d`.", sysctl, value, minimum, sysctl, minimum)
+	}
+	return check
+}
+
+// numericVersion strips suffixes like -ce or +dfsg1 from a version.
+func numericVersion(version string) string {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexFunc(version, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); i >= 0 {
+		version = version[:i]
+	}
+	return version
+}
+
+// preflightDiagnostics turns failed checks into errors and checks with a
+// warning into warnings.
+func preflightDiagnostics(checks []hostCheck) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, check := range checks {
+		switch check.Status {
+		case hostCheckFail:
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Preflight check %s failed", check.Name),
+				Detail:   check.Message,
+			})
+		case hostCheckWarn:
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("Preflight check %s", check.Name),
+				Detail:   check.Message,
+			})
+		}
+	}
+	return diags
+}
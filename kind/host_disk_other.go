@@ -0,0 +1,11 @@
+//go:build !unix
+
+package kind
+
+import "errors"
+
+// freeDiskBytes isn't supported on this platform, container runtimes run in a
+// VM there.
+func freeDiskBytes(path string) (uint64, error) {
+	return 0, errors.New("free disk space can't be determined on this platform")
+}
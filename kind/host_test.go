@@ -0,0 +1,176 @@
+package kind
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+func TestParseRuntimeInfo(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Runtime  string
+		Data     string
+		Expected hostInfo
+	}{
+		{
+			Name:    "Docker",
+			Runtime: "docker",
+			Data:    `{"ServerVersion":"27.3.1","CgroupVersion":"2","SecurityOptions":["name=seccomp,profile=builtin","name=cgroupns"],"DockerRootDir":"/var/lib/docker"}`,
+			Expected: hostInfo{
+				Runtime:        "docker",
+				RuntimeVersion: "27.3.1",
+				CgroupVersion:  "2",
+				DataRoot:       "/var/lib/docker",
+			},
+		},
+		{
+			Name:    "DockerRootless",
+			Runtime: "docker",
+			Data:    `{"ServerVersion":"27.3.1","CgroupVersion":"2","SecurityOptions":["name=seccomp,profile=builtin","name=rootless","name=cgroupns"],"DockerRootDir":"/home/user/.local/share/docker"}`,
+			Expected: hostInfo{
+				Runtime:        "docker",
+				RuntimeVersion: "27.3.1",
+				Rootless:       true,
+				CgroupVersion:  "2",
+				DataRoot:       "/home/user/.local/share/docker",
+			},
+		},
+		{
+			Name:    "Podman",
+			Runtime: "podman",
+			Data:    `{"host":{"cgroupVersion":"v2","security":{"rootless":true}},"store":{"graphRoot":"/home/user/.local/share/containers/storage"},"version":{"Version":"5.2.3"}}`,
+			Expected: hostInfo{
+				Runtime:        "podman",
+				RuntimeVersion: "5.2.3",
+				Rootless:       true,
+				CgroupVersion:  "2",
+				DataRoot:       "/home/user/.local/share/containers/storage",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			info := hostInfo{Runtime: tc.Runtime}
+			if err := parseRuntimeInfo(&info, []byte(tc.Data)); err != nil {
+				t.Fatal(err)
+			}
+			if info.RuntimeVersion != tc.Expected.RuntimeVersion || info.Rootless != tc.Expected.Rootless ||
+				info.CgroupVersion != tc.Expected.CgroupVersion || info.DataRoot != tc.Expected.DataRoot {
+				t.Errorf("expected %+v, got %+v", tc.Expected, info)
+			}
+		})
+	}
+
+	info := hostInfo{Runtime: "docker"}
+	if err := parseRuntimeInfo(&info, []byte("not json")); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	healthy := hostInfo{
+		Runtime:                 "docker",
+		RuntimeVersion:          "27.3.1",
+		CgroupVersion:           "2",
+		DataRoot:                "/var/lib/docker",
+		InotifyMaxUserWatches:   1048576,
+		InotifyMaxUserInstances: 8192,
+		FreeDiskBytes:           100 << 30,
+	}
+
+	cases := []struct {
+		Name     string
+		Modify   func(*hostInfo)
+		Expected map[string]string
+	}{
+		{
+			Name:   "Healthy",
+			Modify: func(*hostInfo) {},
+			Expected: map[string]string{
+				"runtime": hostCheckPass, "runtime_version": hostCheckPass, "cgroup_version": hostCheckPass, "rootless": hostCheckPass,
+				"inotify_max_user_watches": hostCheckPass, "inotify_max_user_instances": hostCheckPass, "free_disk": hostCheckPass,
+			},
+		},
+		{
+			Name:     "RuntimeUnreachable",
+			Modify:   func(i *hostInfo) { i.RuntimeError = errors.New("Cannot connect to the Docker daemon") },
+			Expected: map[string]string{"runtime": hostCheckFail},
+		},
+		{
+			Name:     "OldRuntime",
+			Modify:   func(i *hostInfo) { i.RuntimeVersion = "19.03.15+dfsg1" },
+			Expected: map[string]string{"runtime_version": hostCheckFail},
+		},
+		{
+			Name:     "CgroupV1Rootless",
+			Modify:   func(i *hostInfo) { i.CgroupVersion = "1"; i.Rootless = true },
+			Expected: map[string]string{"cgroup_version": hostCheckFail, "rootless": hostCheckFail},
+		},
+		{
+			Name:     "LowInotifyLimits",
+			Modify:   func(i *hostInfo) { i.InotifyMaxUserWatches = 8192; i.InotifyMaxUserInstances = 128 },
+			Expected: map[string]string{"inotify_max_user_watches": hostCheckWarn, "inotify_max_user_instances": hostCheckWarn},
+		},
+		{
+			Name:     "NotLinux",
+			Modify:   func(i *hostInfo) { i.InotifyMaxUserWatches = -1; i.InotifyMaxUserInstances = -1; i.FreeDiskBytes = -1 },
+			Expected: map[string]string{"inotify_max_user_watches": hostCheckSkip, "inotify_max_user_instances": hostCheckSkip, "free_disk": hostCheckSkip},
+		},
+		{
+			Name:     "LowDisk",
+			Modify:   func(i *hostInfo) { i.FreeDiskBytes = 5 << 30 },
+			Expected: map[string]string{"free_disk": hostCheckWarn},
+		},
+		{
+			Name:     "NoDisk",
+			Modify:   func(i *hostInfo) { i.FreeDiskBytes = 512 << 20 },
+			Expected: map[string]string{"free_disk": hostCheckFail},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			info := healthy
+			tc.Modify(&info)
+			checks := map[string]hostCheck{}
+			for _, check := range checkHost(info) {
+				checks[check.Name] = check
+			}
+			for name, status := range tc.Expected {
+				check, ok := checks[name]
+				if !ok {
+					t.Errorf("expected check %s", name)
+					continue
+				}
+				if check.Status != status {
+					t.Errorf("expected check %s to %s, got %s", name, status, check.Status)
+				}
+				if status != hostCheckPass && check.Message == "" {
+					t.Errorf("expected a message for check %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestPreflightDiagnostics(t *testing.T) {
+	diags := preflightDiagnostics([]hostCheck{
+		{Name: "runtime", Status: hostCheckPass},
+		{Name: "cgroup_version", Status: hostCheckFail, Message: "cgroup v1"},
+		{Name: "inotify_max_user_watches", Status: hostCheckWarn, Message: "too low"},
+		{Name: "free_disk", Status: hostCheckSkip, Message: "unknown"},
+	})
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diags)
+	}
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "cgroup_version") || diags[0].Detail != "cgroup v1" {
+		t.Errorf("unexpected error diagnostic %#v", diags[0])
+	}
+	if diags[1].Severity != diag.Warning || !strings.Contains(diags[1].Summary, "inotify_max_user_watches") {
+		t.Errorf("unexpected warning diagnostic %#v", diags[1])
+	}
+}
@@ -0,0 +1,289 @@
+package kind
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+// archiveMetadataLimit bounds the size of files kept in memory while reading
+// an image archive. Configs, manifests and indexes are a few KiB, layers are
+// skipped.
+const archiveMetadataLimit = 1 << 20
+
+// archiveImage is an image found in a `docker save` tarball.
+type archiveImage struct {
+	// Names are the references the image is tagged with, normalized.
+	Names []string
+	// Digests are the config digest, i.e. the image ID, and the digests of
+	// the manifests and indexes of the image.
+	Digests []string
+	// Config is the config digest of an image listed in manifest.json.
+	Config string
+	// Index is set for an image whose index is part of the archive, which
+	// keeps the digest it was pulled by.
+	Index bool
+}
+
+// archiveManifest is an entry of the manifest.json docker save writes.
+type archiveManifest struct {
+	Config   string
+	RepoTags []string
+}
+
+// ociIndex is an OCI image index, the index.json of an archive or an index
+// blob of a multi platform image.
+type ociIndex struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"manifests"`
+}
+
+// readImageArchive returns the images of a `docker save` tarball, optionally
+// gzip compressed, from its manifest.json and the OCI index.json newer
+// runtimes add.
+func readImageArchive(archivePath string) ([]archiveImage, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("image archive %q not readable: %s", archivePath, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("image archive %q not readable: %s", archivePath, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("image archive %q not readable: %s", archivePath, err)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > archiveMetadataLimit {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("image archive %q not readable: %s", archivePath, err)
+		}
+		files[path.Clean(hdr.Name)] = data
+	}
+
+	images, err := parseImageArchive(files)
+	if err != nil {
+		return nil, fmt.Errorf("image archive %q is not a docker save archive: %s", archivePath, err)
+	}
+	return images, nil
+}
+
+// parseImageArchive collects the images of an archive from its files, keyed
+// by path.
+func parseImageArchive(files map[string][]byte) ([]archiveImage, error) {
+	manifestData, hasManifest := files["manifest.json"]
+	indexData, hasIndex := files["index.json"]
+	if !hasManifest && !hasIndex {
+		return nil, fmt.Errorf("neither manifest.json nor index.json found")
+	}
+
+	// checkBlob verifies the content of a blob against its digest if the blob
+	// is part of the archive
+	checkBlob := func(name, digest string) error {
+		data, ok := files[path.Clean(name)]
+		if !ok {
+			return nil
+		}
+		sum := sha256.Sum256(data)
+		if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digest {
+			return fmt.Errorf("content of %s has digest %s instead of %s", name, actual, digest)
+		}
+		return nil
+	}
+	blobPath := func(digest string) string {
+		return path.Join("blobs", strings.Replace(digest, ":", "/", 1))
+	}
+
+	var images []archiveImage
+	if hasManifest {
+		var manifests []archiveManifest
+		if err := json.Unmarshal(manifestData, &manifests); err != nil {
+			return nil, fmt.Errorf("invalid manifest.json: %s", err)
+		}
+		for _, m := range manifests {
+			config := "sha256:" + strings.TrimSuffix(path.Base(m.Config), ".json")
+			if err := checkBlob(m.Config, config); err != nil {
+				return nil, err
+			}
+			img := archiveImage{Digests: []string{config}, Config: config}
+			for _, tag := range m.RepoTags {
+				img.Names = append(img.Names, normalizeImageName(tag))
+			}
+			images = append(images, img)
+		}
+	}
+
+	if hasIndex {
+		var index ociIndex
+		if err := json.Unmarshal(indexData, &index); err != nil {
+			return nil, fmt.Errorf("invalid index.json: %s", err)
+		}
+		for _, m := range index.Manifests {
+			if err := checkBlob(blobPath(m.Digest), m.Digest); err != nil {
+				return nil, err
+			}
+			img := archiveImage{Digests: []string{m.Digest}, Index: isIndexMediaType(m.MediaType)}
+			for _, key := range []string{"io.containerd.image.name", "org.opencontainers.image.ref.name"} {
+				// ref.name may only hold the tag
+				if name := m.Annotations[key]; strings.ContainsAny(name, "/:") {
+					img.Names = append(img.Names, normalizeImageName(name))
+				}
+			}
+			// a multi platform image also matches the digests of the
+			// manifests it lists
+			var child ociIndex
+			if data := files[blobPath(m.Digest)]; data != nil && json.Unmarshal(data, &child) == nil {
+				img.Index = img.Index || isIndexMediaType(child.MediaType) || len(child.Manifests) > 0
+				for _, c := range child.Manifests {
+					img.Digests = append(img.Digests, c.Digest)
+				}
+			}
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+// isIndexMediaType reports whether a media type is the one of an OCI image
+// index or a docker manifest list.
+func isIndexMediaType(mediaType string) bool {
+	return mediaType == "application/vnd.oci.image.index.v1+json" || mediaType == "application/vnd.docker.distribution.manifest.list.v2+json"
+}
+
+// imageHasTag reports whether an image name, without digest, has a tag.
+func imageHasTag(name string) bool {
+	return strings.LastIndex(name, ":") > strings.LastIndex(name, "/")
+}
+
+// normalizeImageName strips the docker.io and library/ prefixes and adds the
+// latest tag if missing, so differently spelled references compare equal.
+func normalizeImageName(name string) string {
+	name = strings.TrimPrefix(name, "docker.io/")
+	name = strings.TrimPrefix(name, "library/")
+	if !imageHasTag(name) {
+		name += ":latest"
+	}
+	return name
+}
+
+// verifyArchiveImage checks that an archive provides image. A digest pinned
+// image has to match the config or a manifest digest of an image in the
+// archive, other images one of its tags.
+//
+// docker's classic image store doesn't keep the index of a pulled image, its
+// archives only record the config digest and manifests docker generates. A
+// pinned image with a tag is therefore accepted by its tag if the archive has
+// no index for that tag the digest could be checked against.
+func verifyArchiveImage(images []archiveImage, image string) error {
+	name, digest, pinned := strings.Cut(image, "@")
+	found := []string{}
+	tagged, indexed := false, false
+	for _, img := range images {
+		for _, d := range img.Digests {
+			if pinned && d == digest {
+				return nil
+			}
+		}
+		for _, n := range img.Names {
+			if n == normalizeImageName(name) {
+				if !pinned {
+					return nil
+				}
+				tagged = true
+				indexed = indexed || img.Index
+			}
+		}
+		found = append(found, fmt.Sprintf("%s (%s)", strings.Join(img.Names, ", "), strings.Join(img.Digests, ", ")))
+	}
+	if pinned && tagged && !indexed && imageHasTag(name) {
+		return nil
+	}
+	sort.Strings(found)
+	if pinned {
+		return fmt.Errorf("no image with digest %s in the archive, it contains: %s. Archives of docker's classic image store only record the config digest of an image saved without a tag, pin that digest (the image ID) or save the image by its tag", digest, strings.Join(found, "; "))
+	}
+	return fmt.Errorf("no image tagged %s in the archive, it contains: %s", name, strings.Join(found, "; "))
+}
+
+// clusterNodeImages returns the distinct images the nodes of a cluster are
+// created from. Like kind, nodeImage overrides the images of all nodes, nodes
+// without an image use kind's default.
+func clusterNodeImages(nodeImage string, cfg *v1alpha4.Cluster) []string {
+	if nodeImage != "" {
+		return []string{nodeImage}
+	}
+	if cfg == nil || len(cfg.Nodes) == 0 {
+		return []string{kindDefaults.Image}
+	}
+	images := []string{}
+	seen := map[string]bool{}
+	for _, node := range cfg.Nodes {
+		image := node.Image
+		if image == "" {
+			image = kindDefaults.Image
+		}
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// replaceNodeImages makes a cluster use the references of refs, keyed by node
+// image, instead of its node images and returns the node image to create the
+// cluster with, following the same rules as clusterNodeImages.
+func replaceNodeImages(nodeImage string, cfg *v1alpha4.Cluster, refs map[string]string) string {
+	if nodeImage != "" {
+		return refs[nodeImage]
+	}
+	if cfg == nil || len(cfg.Nodes) == 0 {
+		if ref := refs[kindDefaults.Image]; ref != kindDefaults.Image {
+			return ref
+		}
+		return ""
+	}
+	for i := range cfg.Nodes {
+		image := cfg.Nodes[i].Image
+		if image == "" {
+			image = kindDefaults.Image
+		}
+		if ref := refs[image]; ref != image {
+			cfg.Nodes[i].Image = ref
+		}
+	}
+	return ""
+}
@@ -0,0 +1,296 @@
+package kind
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+func testDigest(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// writeTestArchive writes files into a tarball, gzip compressed if requested.
+func writeTestArchive(t *testing.T, files map[string]string, compress bool) string {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w io.Writer = f
+	if compress {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return archive
+}
+
+func TestReadImageArchive(t *testing.T) {
+	config := `{"architecture":"amd64"}`
+	configDigest := testDigest(config)
+	manifest := `{"schemaVersion":2,"config":{"digest":"` + configDigest + `"}}`
+	manifestDigest := testDigest(manifest)
+	index := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[{"digest":"` + manifestDigest + `"}]}`
+	indexDigest := testDigest(index)
+	hexOf := func(digest string) string { return strings.TrimPrefix(digest, "sha256:") }
+
+	cases := []struct {
+		Name     string
+		Files    map[string]string
+		Compress bool
+		Valid    []string
+		Invalid  []string
+	}{
+		{
+			Name: "Docker",
+			Files: map[string]string{
+				hexOf(configDigest) + ".json": config,
+				"manifest.json":               `[{"Config":"` + hexOf(configDigest) + `.json","RepoTags":["kindest/node:v1.36.1"],"Layers":[]}]`,
+			},
+			// without an index the digest of a tagged image can't be checked
+			Valid:   []string{"kindest/node:v1.36.1", "docker.io/kindest/node:v1.36.1", "kindest/node:v1.36.1@" + configDigest, "kindest/node:v1.36.1@" + manifestDigest, "kindest/node@" + configDigest},
+			Invalid: []string{"kindest/node:v1.35.4", "kindest/node@" + manifestDigest},
+		},
+		{
+			Name: "OCI",
+			Files: map[string]string{
+				"blobs/sha256/" + hexOf(configDigest):   config,
+				"blobs/sha256/" + hexOf(manifestDigest): manifest,
+				"blobs/sha256/" + hexOf(indexDigest):    index,
+				"manifest.json":                         `[{"Config":"blobs/sha256/` + hexOf(configDigest) + `","RepoTags":["kindest/node:v1.36.1"],"Layers":[]}]`,
+				"index.json":                            `{"schemaVersion":2,"manifests":[{"digest":"` + indexDigest + `","annotations":{"io.containerd.image.name":"docker.io/kindest/node:v1.36.1","org.opencontainers.image.ref.name":"v1.36.1"}}]}`,
+			},
+			Compress: true,
+			Valid:    []string{"kindest/node:v1.36.1", "kindest/node:v1.36.1@" + indexDigest, "kindest/node:v1.36.1@" + manifestDigest, "kindest/node:v1.36.1@" + configDigest},
+			Invalid:  []string{"kindest/node:latest", "kindest/node:v1.36.1@" + testDigest("other")},
+		},
+		{
+			Name:    "ClassicStore",
+			Files:   testClassicDockerSave(),
+			Valid:   []string{kindDefaults.Image, "kindest/node:v1.36.1"},
+			Invalid: []string{"kindest/node@" + strings.SplitN(kindDefaults.Image, "@", 2)[1], "kindest/node:v1.35.4@" + strings.SplitN(kindDefaults.Image, "@", 2)[1]},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			images, err := readImageArchive(writeTestArchive(t, tc.Files, tc.Compress))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, image := range tc.Valid {
+				if err := verifyArchiveImage(images, image); err != nil {
+					t.Errorf("expected %s to be provided, got %s", image, err)
+				}
+			}
+			for _, image := range tc.Invalid {
+				if err := verifyArchiveImage(images, image); err == nil {
+					t.Errorf("expected %s not to be provided", image)
+				}
+			}
+		})
+	}
+
+	if _, err := readImageArchive(writeTestArchive(t, map[string]string{"layer.tar": "data"}, false)); err == nil {
+		t.Error("expected an error for an archive without manifest.json and index.json")
+	}
+	// the config file name doesn't match its content
+	tampered := map[string]string{
+		hexOf(configDigest) + ".json": `{"architecture":"arm64"}`,
+		"manifest.json":               `[{"Config":"` + hexOf(configDigest) + `.json","RepoTags":["kindest/node:v1.36.1"],"Layers":[]}]`,
+	}
+	if _, err := readImageArchive(writeTestArchive(t, tampered, false)); err == nil {
+		t.Error("expected an error for a config not matching its digest")
+	}
+}
+
+// testClassicDockerSave returns the files `docker save kindest/node:v1.36.1`
+// writes with docker's classic image store for the image pulled by kind's
+// default, manifest list pinned, reference. Only the config digest of the
+// image is recorded, neither the manifest list nor the manifests are kept.
+func testClassicDockerSave() map[string]string {
+	layer := "layer content"
+	config := `{"architecture":"amd64","config":{"Entrypoint":["/usr/local/bin/entrypoint","/sbin/init"],"StopSignal":"SIGRTMIN+3"},"os":"linux","rootfs":{"type":"layers","diff_ids":["` + testDigest(layer) + `"]}}`
+	configHex := strings.TrimPrefix(testDigest(config), "sha256:")
+	layerID := strings.TrimPrefix(testDigest("v1 layer id"), "sha256:")
+	return map[string]string{
+		configHex + ".json":    config,
+		layerID + "/VERSION":   "1.0",
+		layerID + "/json":      `{"id":"` + layerID + `","os":"linux"}`,
+		layerID + "/layer.tar": layer,
+		"manifest.json":        `[{"Config":"` + configHex + `.json","RepoTags":["kindest/node:v1.36.1"],"Layers":["` + layerID + `/layer.tar"]}]`,
+		"repositories":         `{"kindest/node":{"v1.36.1":"` + layerID + `"}}`,
+	}
+}
+
+func TestLoadNodeImageArchive_DigestMismatch(t *testing.T) {
+	// the archive is rejected before the container runtime is run
+	t.Setenv("KIND_EXPERIMENTAL_PROVIDER", "docker")
+	t.Setenv("PATH", t.TempDir())
+	archive := writeTestArchive(t, map[string]string{
+		"manifest.json": `[{"Config":"` + strings.Repeat("a", 64) + `.json","RepoTags":["kindest/node:v1.36.1"],"Layers":[]}]`,
+	}, false)
+	_, err := loadNodeImageArchive(archive, []string{"kindest/node@sha256:" + strings.Repeat("b", 64)})
+	if err == nil || !strings.Contains(err.Error(), "no image with digest") {
+		t.Errorf("expected a digest mismatch, got %v", err)
+	}
+}
+
+func TestLoadNodeImageArchive_ClassicStore(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("fake docker binary is a shell script")
+	}
+	files := testClassicDockerSave()
+	var manifests []archiveManifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifests); err != nil {
+		t.Fatal(err)
+	}
+	configDigest := testDigest(files[manifests[0].Config])
+	archive := writeTestArchive(t, files, false)
+
+	// a docker that, like the classic image store, resolves the loaded image
+	// by its tag and ID but not by the digest it was pulled by
+	bin := t.TempDir()
+	tags := filepath.Join(t.TempDir(), "tags")
+	script := `#!/bin/sh
+for last; do :; done
+case "$1" in
+load) exit 0;;
+tag) echo "$2 $3" >> ` + tags + `; exit 0;;
+image)
+	case "$last" in
+	kindest/node:v1.36.1|` + configDigest + `) echo ` + configDigest + `; exit 0;;
+	esac;;
+esac
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("KIND_EXPERIMENTAL_PROVIDER", "docker")
+
+	byConfig := "kindest/node@" + configDigest
+	refs, err := loadNodeImageArchive(archive, []string{kindDefaults.Image, byConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		kindDefaults.Image: "kindest/node:v1.36.1",
+		byConfig:           "kindest/node:" + strings.Replace(configDigest, ":", "-", 1),
+	}
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("expected %v, got %v", expected, refs)
+	}
+	data, err := os.ReadFile(tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTags := configDigest + " kindest/node:v1.36.1\n" + configDigest + " " + expected[byConfig] + "\n"
+	if string(data) != expectedTags {
+		t.Errorf("expected tags %q, got %q", expectedTags, data)
+	}
+}
+
+func TestAccLoadNodeImageArchive_DockerSave(t *testing.T) {
+	if os.Getenv(resource.EnvTfAcc) == "" {
+		t.Skip("Acceptance tests skipped unless env 'TF_ACC' set")
+	}
+	// kind's default image is pinned to its manifest list, which isn't part
+	// of archives written with docker's classic image store
+	tag, _, _ := strings.Cut(kindDefaults.Image, "@")
+	archive := filepath.Join(t.TempDir(), "node-image.tar")
+	for _, c := range [][]string{
+		{"docker", "pull", kindDefaults.Image},
+		{"docker", "tag", kindDefaults.Image, tag},
+		{"docker", "save", "-o", archive, tag},
+	} {
+		if out, err := exec.Command(c[0], c[1:]...).CombinedOutput(); err != nil {
+			t.Fatalf("failed to run %v: %s\n%s", c, err, out)
+		}
+	}
+	t.Setenv("KIND_EXPERIMENTAL_PROVIDER", "docker")
+
+	refs, err := loadNodeImageArchive(archive, []string{kindDefaults.Image})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localImageID(refs[kindDefaults.Image]); err != nil {
+		t.Errorf("expected %s to resolve after loading, got %s", refs[kindDefaults.Image], err)
+	}
+}
+
+func TestReplaceNodeImages(t *testing.T) {
+	refs := map[string]string{kindDefaults.Image: "kindest/node:v1.36.1", "kindest/node:v1.35.4": "kindest/node:v1.35.4"}
+	if got := replaceNodeImages("", nil, refs); got != "kindest/node:v1.36.1" {
+		t.Errorf("expected the default image to be replaced, got %q", got)
+	}
+	cfg := &v1alpha4.Cluster{Nodes: []v1alpha4.Node{
+		{Role: v1alpha4.ControlPlaneRole},
+		{Role: v1alpha4.WorkerRole, Image: "kindest/node:v1.35.4"},
+	}}
+	if got := replaceNodeImages("", cfg, refs); got != "" {
+		t.Errorf("expected no node image, got %q", got)
+	}
+	if cfg.Nodes[0].Image != "kindest/node:v1.36.1" || cfg.Nodes[1].Image != "kindest/node:v1.35.4" {
+		t.Errorf("unexpected node images %q, %q", cfg.Nodes[0].Image, cfg.Nodes[1].Image)
+	}
+	if got := replaceNodeImages(kindDefaults.Image, nil, refs); got != "kindest/node:v1.36.1" {
+		t.Errorf("expected node_image to be replaced, got %q", got)
+	}
+}
+
+func TestClusterNodeImages(t *testing.T) {
+	cfg := &v1alpha4.Cluster{Nodes: []v1alpha4.Node{
+		{Role: v1alpha4.ControlPlaneRole},
+		{Role: v1alpha4.WorkerRole, Image: "kindest/node:v1.35.4"},
+		{Role: v1alpha4.WorkerRole, Image: "kindest/node:v1.35.4"},
+	}}
+
+	cases := []struct {
+		Name      string
+		NodeImage string
+		Config    *v1alpha4.Cluster
+		Expected  []string
+	}{
+		{Name: "Default", Expected: []string{kindDefaults.Image}},
+		{Name: "NodeImage", NodeImage: "kindest/node:v1.34.7", Config: cfg, Expected: []string{"kindest/node:v1.34.7"}},
+		{Name: "PerNode", Config: cfg, Expected: []string{kindDefaults.Image, "kindest/node:v1.35.4"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := clusterNodeImages(tc.NodeImage, tc.Config)
+			if strings.Join(got, ",") != strings.Join(tc.Expected, ",") {
+				t.Errorf("expected %v, got %v", tc.Expected, got)
+			}
+		})
+	}
+}
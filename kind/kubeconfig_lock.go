@@ -0,0 +1,96 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeconfigLockedError is part of the error kind returns when the lock file
+// of a kubeconfig is held by someone else. kind fails right away instead of
+// waiting for it.
+const kubeconfigLockedError = "failed to lock config file"
+
+// kubeconfigLockTimeout bounds how long a kubeconfig write waits for the lock
+// file, in case it was left behind by a process that was killed.
+const kubeconfigLockTimeout = time.Minute
+
+// kubeconfigLocks serializes kubeconfig writes of the resources served by
+// this provider process, keyed by kubeconfig file. The values are channels
+// with a capacity of one, holding the lock means having sent to it, so waiting
+// for it can be cancelled.
+var kubeconfigLocks sync.Map
+
+// kubeconfigFile returns the kubeconfig kind writes to for an explicit
+// kubeconfig_path, the first KUBECONFIG entry or ~/.kube/config otherwise.
+func kubeconfigFile(path string) string {
+	if path == "" {
+		if paths := filepath.SplitList(os.Getenv(clientcmd.RecommendedConfigPathEnvVar)); len(paths) > 0 && paths[0] != "" {
+			path = paths[0]
+		} else {
+			path = clientcmd.RecommendedHomeFile
+		}
+	}
+	return filepath.Clean(path)
+}
+
+// withKubeconfigLock runs fn, which writes the kubeconfig at path, while no
+// other resource of the provider writes to it. Waiting for the other resources
+// stops when ctx is done. fn is retried while another process holds the lock
+// file, until ctx is done or kubeconfigLockTimeout passed.
+func withKubeconfigLock(ctx context.Context, path string, fn func() error) error {
+	file := kubeconfigFile(path)
+	lock, _ := kubeconfigLocks.LoadOrStore(file, make(chan struct{}, 1))
+	select {
+	case lock.(chan struct{}) <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for other resources writing kubeconfig %s: %w", file, ctx.Err())
+	}
+	defer func() { <-lock.(chan struct{}) }()
+
+	deadline := time.After(kubeconfigLockTimeout)
+	backoff := 50 * time.Millisecond
+	for {
+		err := fn()
+		if err == nil || !strings.Contains(err.Error(), kubeconfigLockedError) {
+			return err
+		}
+		tflog.Debug(ctx, "Kubeconfig is locked, retrying", map[string]interface{}{"kubeconfig": file, "error": err.Error()})
+		select {
+		case <-ctx.Done():
+			return err
+		case <-deadline:
+			return fmt.Errorf("%w, remove %s if no other process is writing the kubeconfig", err, file+".lock")
+		case <-time.After(backoff):
+		}
+		if backoff < 2*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// lockKubeconfig creates the lock file kind, kubectl and client-go use for
+// the kubeconfig at path and returns a function removing it. Use it with
+// withKubeconfigLock to retry while the kubeconfig is locked.
+func lockKubeconfig(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	lockFile := path + ".lock"
+	f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL, 0)
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("%s %s: %w", kubeconfigLockedError, path, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return func() { os.Remove(lockFile) }, nil
+}
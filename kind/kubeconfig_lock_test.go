@@ -0,0 +1,208 @@
+package kind
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/kind/pkg/cluster"
+)
+
+func TestKubeconfigFile(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first"), filepath.Join(dir, "second")
+
+	t.Setenv("KUBECONFIG", "")
+	if got := kubeconfigFile(""); got != clientcmd.RecommendedHomeFile {
+		t.Errorf("expected %s, got %s", clientcmd.RecommendedHomeFile, got)
+	}
+	t.Setenv("KUBECONFIG", first+string(filepath.ListSeparator)+second)
+	if got := kubeconfigFile(""); got != first {
+		t.Errorf("expected %s, got %s", first, got)
+	}
+	if got := kubeconfigFile(second + "/"); got != second {
+		t.Errorf("expected %s, got %s", second, got)
+	}
+}
+
+func TestWithKubeconfigLock_WaitsForLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	config := clientcmdapi.NewConfig()
+	config.Clusters["kind-test"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	config.AuthInfos["kind-test"] = &clientcmdapi.AuthInfo{Token: "token"}
+	config.Contexts["kind-test"] = &clientcmdapi.Context{Cluster: "kind-test", AuthInfo: "kind-test"}
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// another process, e.g. kubectl, holds the lock file for a while
+	unlock, err := lockKubeconfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		unlock()
+	}()
+
+	if err := withKubeconfigLock(context.Background(), path, func() error {
+		return removeKubeContext(path, "kind-test", "test")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := loaded.Contexts["kind-test"]; exists {
+		t.Error("context kind-test should have been removed")
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("expected the lock file to be removed, got %v", err)
+	}
+}
+
+func TestWithKubeconfigLock_Serializes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			withKubeconfigLock(context.Background(), path, func() error {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	if maxRunning != 1 {
+		t.Errorf("expected writes to the same kubeconfig to be serialized, %d ran at the same time", maxRunning)
+	}
+}
+
+func TestWithKubeconfigLock_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	calls := 0
+	err := withKubeconfigLock(context.Background(), path, func() error {
+		calls++
+		return errors.New("failed to read kubeconfig")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("expected other errors to be returned without retrying, got %v after %d calls", err, calls)
+	}
+
+	// the lock file is held by another process that never releases it
+	unlock, err := lockKubeconfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = withKubeconfigLock(ctx, path, func() error {
+		unlock, err := lockKubeconfig(path)
+		if err == nil {
+			unlock()
+		}
+		return err
+	})
+	if err == nil {
+		t.Error("expected an error once the context is done")
+	}
+}
+
+func TestWithKubeconfigLock_Cancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	// another resource writes the kubeconfig and doesn't finish
+	holding, release := make(chan struct{}), make(chan struct{})
+	go withKubeconfigLock(context.Background(), path, func() error {
+		close(holding)
+		<-release
+		return nil
+	})
+	defer close(release)
+	<-holding
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	called := false
+	err := withKubeconfigLock(ctx, path, func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || called {
+		t.Errorf("expected waiting for the lock to stop with the context, got %v", err)
+	}
+}
+
+func TestWithKubeconfigLock_RetriesKind(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("fake docker binary is a shell script")
+	}
+	// a docker without containers, so deleting a cluster only makes kind
+	// remove its context from the kubeconfig
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	provider := cluster.NewProvider(cluster.ProviderWithDocker())
+
+	path := filepath.Join(t.TempDir(), "config")
+	config := clientcmdapi.NewConfig()
+	config.Clusters["kind-test"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	config.AuthInfos["kind-test"] = &clientcmdapi.AuthInfo{Token: "token"}
+	config.Contexts["kind-test"] = &clientcmdapi.Context{Cluster: "kind-test", AuthInfo: "kind-test"}
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// another process, e.g. kubectl, holds the lock file
+	unlock, err := lockKubeconfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.Delete("test", path); err == nil || !strings.Contains(err.Error(), kubeconfigLockedError) {
+		t.Fatalf("expected kind to fail with %q while the lock file is held, got %v", kubeconfigLockedError, err)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		unlock()
+	}()
+	if err := withKubeconfigLock(context.Background(), path, func() error {
+		return provider.Delete("test", path)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := loaded.Contexts["kind-test"]; exists {
+		t.Error("context kind-test should have been removed by kind")
+	}
+}
@@ -0,0 +1,258 @@
+package kind
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/kind/pkg/cluster"
+)
+
+// manifestMappingTimeout bounds how long applying waits for the API server to
+// serve a kind, e.g. a custom resource whose CRD was just applied.
+const manifestMappingTimeout = time.Minute
+
+// manifestObject identifies an object applied from a manifest.
+type manifestObject struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+func (o manifestObject) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s %s %s", o.APIVersion, o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s %s %s/%s", o.APIVersion, o.Kind, o.Namespace, o.Name)
+}
+
+// restConfigForCluster returns a client config for a kind cluster using the
+// kubeconfig kind generates for it.
+func restConfigForCluster(provider *cluster.Provider, clusterName string) (*rest.Config, error) {
+	kconfig, err := provider.KubeConfig(clusterName, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig for cluster %q: %s", clusterName, err)
+	}
+	return clientcmd.RESTConfigFromKubeConfig([]byte(kconfig))
+}
+
+// parseManifest splits a multi document YAML or JSON manifest into objects,
+// skipping empty documents.
+func parseManifest(content string) ([]*unstructured.Unstructured, error) {
+	objects := []*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(content)), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse manifest: %s", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("manifest object is missing apiVersion, kind or metadata.name: %v", obj.Object)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// manifestClient applies and deletes manifest objects through the dynamic
+// client.
+type manifestClient struct {
+	dynamic dynamic.Interface
+	mapper  *restmapper.DeferredDiscoveryRESTMapper
+}
+
+func newManifestClient(config *rest.Config) (*manifestClient, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &manifestClient{
+		dynamic: dyn,
+		mapper:  restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc)),
+	}, nil
+}
+
+// resourceFor returns the client for an object, defaulting the namespace of
+// namespaced objects. With waitForKind, kinds unknown to the API server are
+// retried for a while since their CRD may just have been created, otherwise
+// the NoKindMatchError is returned as is.
+func (c *manifestClient) resourceFor(ctx context.Context, obj manifestObject, waitForKind bool) (dynamic.ResourceInterface, manifestObject, error) {
+	gv, err := k8sschema.ParseGroupVersion(obj.APIVersion)
+	if err != nil {
+		return nil, obj, err
+	}
+
+	deadline := time.Now().Add(manifestMappingTimeout)
+	var mapping *meta.RESTMapping
+	for {
+		mapping, err = c.mapper.RESTMapping(gv.WithKind(obj.Kind).GroupKind(), gv.Version)
+		if err == nil {
+			break
+		}
+		if meta.IsNoMatchError(err) && !waitForKind {
+			return nil, obj, err
+		}
+		if !meta.IsNoMatchError(err) || time.Now().After(deadline) {
+			return nil, obj, fmt.Errorf("failed to map %s: %s", obj, err)
+		}
+		c.mapper.Reset()
+		select {
+		case <-ctx.Done():
+			return nil, obj, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		obj.Namespace = ""
+		return c.dynamic.Resource(mapping.Resource), obj, nil
+	}
+	if obj.Namespace == "" {
+		obj.Namespace = metav1.NamespaceDefault
+	}
+	return c.dynamic.Resource(mapping.Resource).Namespace(obj.Namespace), obj, nil
+}
+
+// apply server-side applies an object and returns its identity.
+func (c *manifestClient) apply(ctx context.Context, u *unstructured.Unstructured, fieldManager string, force bool) (manifestObject, error) {
+	obj := manifestObjectFor(u)
+	ri, obj, err := c.resourceFor(ctx, obj, true)
+	if err != nil {
+		return obj, err
+	}
+	if _, err := applyPatch(ctx, ri, obj, u, fieldManager, force, false); err != nil {
+		return obj, fmt.Errorf("failed to apply %s: %s", obj, err)
+	}
+	return obj, nil
+}
+
+// drifted reports whether applying an object again would change it, e.g.
+// because fields set by the manifest were edited or the object is gone. It
+// server-side applies the object as a dry run and compares the result with
+// the live object.
+func (c *manifestClient) drifted(ctx context.Context, u *unstructured.Unstructured, fieldManager string, force bool) (bool, error) {
+	ri, obj, err := c.resourceFor(ctx, manifestObjectFor(u), false)
+	if meta.IsNoMatchError(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	live, err := ri.Get(ctx, obj.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	applied, err := applyPatch(ctx, ri, obj, u, fieldManager, force, true)
+	if apierrors.IsConflict(err) {
+		// another field manager changed fields of the manifest
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to dry run apply of %s: %s", obj, err)
+	}
+	return !manifestObjectsEqual(live, applied), nil
+}
+
+// applyPatch server-side applies u as obj, the identity resourceFor
+// returned.
+func applyPatch(ctx context.Context, ri dynamic.ResourceInterface, obj manifestObject, u *unstructured.Unstructured, fieldManager string, force, dryRun bool) (*unstructured.Unstructured, error) {
+	u = u.DeepCopy()
+	u.SetNamespace(obj.Namespace)
+	data, err := u.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	opts := metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &force,
+	}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	return ri.Patch(ctx, obj.Name, types.ApplyPatchType, data, opts)
+}
+
+// manifestObjectsEqual compares a live object with the result of applying
+// the manifest to it, ignoring the bookkeeping an apply changes.
+func manifestObjectsEqual(live, applied *unstructured.Unstructured) bool {
+	strip := func(u *unstructured.Unstructured) map[string]interface{} {
+		u = u.DeepCopy()
+		u.SetManagedFields(nil)
+		u.SetResourceVersion("")
+		u.SetGeneration(0)
+		return u.Object
+	}
+	return equality.Semantic.DeepEqual(strip(live), strip(applied))
+}
+
+// exists reports whether an applied object is still present.
+func (c *manifestClient) exists(ctx context.Context, obj manifestObject) (bool, error) {
+	ri, obj, err := c.resourceFor(ctx, obj, false)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = ri.Get(ctx, obj.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// delete removes an applied object, objects already gone are ignored.
+func (c *manifestClient) delete(ctx context.Context, obj manifestObject) error {
+	ri, obj, err := c.resourceFor(ctx, obj, false)
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	propagation := metav1.DeletePropagationBackground
+	err = ri.Delete(ctx, obj.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s: %s", obj, err)
+	}
+	return nil
+}
+
+func manifestObjectFor(u *unstructured.Unstructured) manifestObject {
+	return manifestObject{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+	}
+}
@@ -0,0 +1,128 @@
+package kind
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseManifest(t *testing.T) {
+	cases := []struct {
+		Name          string
+		Content       string
+		ExpectedNames []string
+		ExpectError   bool
+	}{
+		{
+			Name:          "EmptyManifest",
+			Content:       "",
+			ExpectedNames: []string{},
+		},
+		{
+			Name: "MultipleDocumentsWithEmptyOnes",
+			Content: `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+# only a comment
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: demo
+data:
+  key: value
+`,
+			ExpectedNames: []string{"demo", "settings"},
+		},
+		{
+			Name:          "JSON",
+			Content:       `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "json"}}`,
+			ExpectedNames: []string{"json"},
+		},
+		{
+			Name: "MissingNameIsAnError",
+			Content: `apiVersion: v1
+kind: ConfigMap
+`,
+			ExpectError: true,
+		},
+		{
+			Name:        "InvalidYAMLIsAnError",
+			Content:     "apiVersion: [v1",
+			ExpectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			objects, err := parseManifest(tc.Content)
+			if tc.ExpectError {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(objects) != len(tc.ExpectedNames) {
+				t.Fatalf("expected %d objects, got %d", len(tc.ExpectedNames), len(objects))
+			}
+			for i, name := range tc.ExpectedNames {
+				if objects[i].GetName() != name {
+					t.Errorf("expected object %d to be named %q, got %q", i, name, objects[i].GetName())
+				}
+			}
+		})
+	}
+}
+
+func TestManifestObjectString(t *testing.T) {
+	namespaced := manifestObject{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}
+	if got := namespaced.String(); got != "apps/v1 Deployment default/web" {
+		t.Errorf("unexpected string %q", got)
+	}
+	clusterScoped := manifestObject{APIVersion: "v1", Kind: "Namespace", Name: "demo"}
+	if got := clusterScoped.String(); got != "v1 Namespace demo" {
+		t.Errorf("unexpected string %q", got)
+	}
+}
+
+func TestManifestObjectsEqual(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":            "settings",
+			"namespace":       "default",
+			"resourceVersion": "1",
+			"managedFields":   []interface{}{map[string]interface{}{"manager": "terraform-provider-kind", "time": "2026-10-19T10:00:00Z"}},
+		},
+		"data": map[string]interface{}{"key": "value"},
+	}}
+
+	applied := live.DeepCopy()
+	applied.SetResourceVersion("2")
+	applied.SetManagedFields(nil)
+	if !manifestObjectsEqual(live, applied) {
+		t.Error("expected objects differing in bookkeeping only to be equal")
+	}
+
+	applied = live.DeepCopy()
+	if err := unstructured.SetNestedField(applied.Object, "other", "data", "key"); err != nil {
+		t.Fatal(err)
+	}
+	if manifestObjectsEqual(live, applied) {
+		t.Error("expected a changed field to be detected")
+	}
+
+	applied = live.DeepCopy()
+	applied.SetLabels(map[string]string{"app": "web"})
+	if manifestObjectsEqual(live, applied) {
+		t.Error("expected an added label to be detected")
+	}
+}
@@ -0,0 +1,137 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sigs.k8s.io/kind/pkg/cluster"
+	kindLog "sigs.k8s.io/kind/pkg/log"
+)
+
+// kindLogSubsystem is the tflog subsystem kind's own output is logged to. Its
+// level can be set on its own with TF_LOG_PROVIDER_KIND_KIND.
+const kindLogSubsystem = "kind"
+
+// kindLogLevelEnvs are the environment variables setting the level of the
+// kind subsystem, most specific first.
+var kindLogLevelEnvs = []string{
+	"TF_LOG_PROVIDER_KIND_KIND",
+	"TF_LOG_PROVIDER_KIND",
+	"TF_LOG_PROVIDER",
+	"TF_LOG",
+}
+
+// kindLogger implements kind's log.Logger by forwarding to tflog, so kind's
+// progress output ends up in the Terraform logs with the fields of the
+// resource that triggered it. Warnings and errors keep their level, info
+// messages are mapped by verbosity: V(0) logs at INFO, V(1) at DEBUG and
+// anything more verbose at TRACE.
+type kindLogger struct {
+	ctx context.Context
+}
+
+var _ kindLog.Logger = &kindLogger{}
+
+// newKindLogger returns a kind logger for the kind subsystem of ctx, carrying
+// the fields set on ctx with tflog.SetField.
+func newKindLogger(ctx context.Context) *kindLogger {
+	ctx = tflog.NewSubsystem(ctx, kindLogSubsystem,
+		tflog.WithLevelFromEnv("TF_LOG_PROVIDER_KIND", kindLogSubsystem),
+		tflog.WithRootFields(),
+	)
+	return &kindLogger{ctx: ctx}
+}
+
+// newKindProvider returns a kind cluster provider logging through tflog.
+func newKindProvider(ctx context.Context) *cluster.Provider {
+	return cluster.NewProvider(cluster.ProviderWithLogger(newKindLogger(ctx)))
+}
+
+func (l *kindLogger) Warn(message string) {
+	tflog.SubsystemWarn(l.ctx, kindLogSubsystem, trimLogMessage(message))
+}
+
+func (l *kindLogger) Warnf(format string, args ...interface{}) {
+	l.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l *kindLogger) Error(message string) {
+	tflog.SubsystemError(l.ctx, kindLogSubsystem, trimLogMessage(message))
+}
+
+func (l *kindLogger) Errorf(format string, args ...interface{}) {
+	l.Error(fmt.Sprintf(format, args...))
+}
+
+func (l *kindLogger) V(level kindLog.Level) kindLog.InfoLogger {
+	return &kindInfoLogger{ctx: l.ctx, level: kindLogLevel(level)}
+}
+
+// kindInfoLogger logs kind info messages of one verbosity at the matching
+// Terraform log level.
+type kindInfoLogger struct {
+	ctx   context.Context
+	level hclog.Level
+}
+
+func (l *kindInfoLogger) Info(message string) {
+	message = trimLogMessage(message)
+	switch l.level {
+	case hclog.Info:
+		tflog.SubsystemInfo(l.ctx, kindLogSubsystem, message)
+	case hclog.Debug:
+		tflog.SubsystemDebug(l.ctx, kindLogSubsystem, message)
+	default:
+		tflog.SubsystemTrace(l.ctx, kindLogSubsystem, message)
+	}
+}
+
+func (l *kindInfoLogger) Infof(format string, args ...interface{}) {
+	l.Info(fmt.Sprintf(format, args...))
+}
+
+// Enabled reports whether messages of this verbosity are logged, kind uses it
+// to skip collecting verbose output like command logs.
+func (l *kindInfoLogger) Enabled() bool {
+	configured := configuredKindLogLevel()
+	return configured != hclog.NoLevel && configured != hclog.Off && configured <= l.level
+}
+
+// kindLogLevel maps a kind verbosity to a Terraform log level.
+func kindLogLevel(level kindLog.Level) hclog.Level {
+	switch {
+	case level <= 0:
+		return hclog.Info
+	case level == 1:
+		return hclog.Debug
+	default:
+		return hclog.Trace
+	}
+}
+
+// configuredKindLogLevel returns the log level of the kind subsystem from the
+// environment, hclog.NoLevel if logging is disabled. TF_LOG=JSON logs
+// everything like TRACE.
+func configuredKindLogLevel() hclog.Level {
+	for _, env := range kindLogLevelEnvs {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		if strings.EqualFold(value, "json") {
+			return hclog.Trace
+		}
+		return hclog.LevelFromString(value)
+	}
+	return hclog.NoLevel
+}
+
+// trimLogMessage removes the trailing newlines kind adds for its terminal
+// output.
+func trimLogMessage(message string) string {
+	return strings.TrimRight(message, "\n")
+}
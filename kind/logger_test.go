@@ -0,0 +1,106 @@
+package kind
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	kindLog "sigs.k8s.io/kind/pkg/log"
+)
+
+func TestKindLogger(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	ctx = tflog.SetField(ctx, "cluster_name", "test")
+
+	logger := newKindLogger(ctx)
+	logger.V(0).Info("Creating cluster \"test\" ...\n")
+	logger.V(1).Infof("Using node image %s", "kindest/node")
+	logger.V(3).Info("running command")
+	logger.Warnf("unable to %s", "cleanup")
+	logger.Error("failed")
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		Level   string
+		Message string
+	}{
+		{"info", `Creating cluster "test" ...`},
+		{"debug", "Using node image kindest/node"},
+		{"trace", "running command"},
+		{"warn", "unable to cleanup"},
+		{"error", "failed"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d log entries, got %d: %v", len(expected), len(entries), entries)
+	}
+	for i, e := range expected {
+		entry := entries[i]
+		if entry["@level"] != e.Level || entry["@message"] != e.Message {
+			t.Errorf("entry %d: expected %s %q, got %s %q", i, e.Level, e.Message, entry["@level"], entry["@message"])
+		}
+		if entry["@module"] != "provider."+kindLogSubsystem {
+			t.Errorf("entry %d: expected module provider.%s, got %v", i, kindLogSubsystem, entry["@module"])
+		}
+		if entry["cluster_name"] != "test" {
+			t.Errorf("entry %d: expected cluster_name field test, got %v", i, entry["cluster_name"])
+		}
+	}
+}
+
+func TestKindInfoLoggerEnabled(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Env     map[string]string
+		Enabled map[kindLog.Level]bool
+	}{
+		{
+			Name:    "Unset",
+			Enabled: map[kindLog.Level]bool{0: false, 1: false, 2: false},
+		},
+		{
+			Name:    "Info",
+			Env:     map[string]string{"TF_LOG": "INFO"},
+			Enabled: map[kindLog.Level]bool{0: true, 1: false, 2: false},
+		},
+		{
+			Name:    "JSON",
+			Env:     map[string]string{"TF_LOG": "JSON"},
+			Enabled: map[kindLog.Level]bool{0: true, 1: true, 2: true},
+		},
+		{
+			Name:    "ProviderOverridesCore",
+			Env:     map[string]string{"TF_LOG": "TRACE", "TF_LOG_PROVIDER": "DEBUG"},
+			Enabled: map[kindLog.Level]bool{0: true, 1: true, 2: false},
+		},
+		{
+			Name:    "SubsystemOverridesProvider",
+			Env:     map[string]string{"TF_LOG_PROVIDER_KIND": "TRACE", "TF_LOG_PROVIDER_KIND_KIND": "WARN"},
+			Enabled: map[kindLog.Level]bool{0: false, 1: false, 2: false},
+		},
+		{
+			Name:    "Off",
+			Env:     map[string]string{"TF_LOG": "OFF"},
+			Enabled: map[kindLog.Level]bool{0: false, 1: false, 2: false},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			for _, env := range kindLogLevelEnvs {
+				t.Setenv(env, tc.Env[env])
+			}
+			logger := newKindLogger(context.Background())
+			for level, enabled := range tc.Enabled {
+				if got := logger.V(level).Enabled(); got != enabled {
+					t.Errorf("V(%d): expected Enabled %t, got %t", level, enabled, got)
+				}
+			}
+		})
+	}
+}
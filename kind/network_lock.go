@@ -0,0 +1,77 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// kindNetworkLock is held by the cluster create setting up the network all
+// kind clusters share. kind creates the network with the first node of a
+// cluster if it is missing, clusters created at the same time would each
+// create one. It is a channel with a capacity of one so waiting for it can be
+// cancelled.
+var kindNetworkLock = make(chan struct{}, 1)
+
+// kindNetworkPollInterval is how often the create holding kindNetworkLock
+// checks whether kind created the network yet.
+var kindNetworkPollInterval = time.Second
+
+// kindNetworkExists reports whether the network kind attaches nodes to exists
+// in the container runtime.
+func kindNetworkExists() bool {
+	return exec.Command(containerRuntime(), "network", "inspect", kindNetwork()).Run() == nil
+}
+
+// acquireKindNetwork serializes cluster creation while the kind network is
+// missing and returns a function to call once the cluster is created. The
+// first create holds the lock until the network shows up or it is done, the
+// others then find the network and go on in parallel. Waiting stops when ctx
+// is done.
+func acquireKindNetwork(ctx context.Context) (func(), error) {
+	if kindNetworkExists() {
+		return func() {}, nil
+	}
+	select {
+	case kindNetworkLock <- struct{}{}:
+	default:
+		tflog.Info(ctx, "Waiting for another cluster to create the network", map[string]interface{}{"network": kindNetwork()})
+		select {
+		case kindNetworkLock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for another cluster to create the %s network: %w", kindNetwork(), ctx.Err())
+		}
+	}
+	// the create holding the lock before may have created it
+	if kindNetworkExists() {
+		<-kindNetworkLock
+		return func() {}, nil
+	}
+
+	var once sync.Once
+	unlock := func() { once.Do(func() { <-kindNetworkLock }) }
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(kindNetworkPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if kindNetworkExists() {
+					unlock()
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		unlock()
+	}, nil
+}
@@ -0,0 +1,105 @@
+package kind
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+	"time"
+)
+
+// fakeKindNetwork installs a docker whose kind network exists once the
+// returned file is created.
+func fakeKindNetwork(t *testing.T) string {
+	t.Helper()
+	if goruntime.GOOS == "windows" {
+		t.Skip("fake docker binary is a shell script")
+	}
+	bin := t.TempDir()
+	network := filepath.Join(t.TempDir(), "network")
+	script := "#!/bin/sh\n[ \"$1 $2\" = \"network inspect\" ] && [ -f " + network + " ]\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("KIND_EXPERIMENTAL_PROVIDER", "docker")
+
+	interval := kindNetworkPollInterval
+	kindNetworkPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { kindNetworkPollInterval = interval })
+	return network
+}
+
+func TestAcquireKindNetwork_SerializesUntilCreated(t *testing.T) {
+	network := fakeKindNetwork(t)
+
+	// the first create sets up the network
+	unlock, err := acquireKindNetwork(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	acquired := make(chan error)
+	go func() {
+		unlock, err := acquireKindNetwork(context.Background())
+		if err == nil {
+			unlock()
+		}
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		t.Fatalf("expected the second create to wait for the network, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// kind created the network, the first create is still running
+	if err := os.WriteFile(network, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the second create to continue once the network exists")
+	}
+}
+
+func TestAcquireKindNetwork_Exists(t *testing.T) {
+	network := fakeKindNetwork(t)
+	if err := os.WriteFile(network, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// creates don't wait for each other
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		unlock, err := acquireKindNetwork(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer unlock()
+	}
+}
+
+func TestAcquireKindNetwork_Cancel(t *testing.T) {
+	fakeKindNetwork(t)
+
+	unlock, err := acquireKindNetwork(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := acquireKindNetwork(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected waiting for the network to stop with the context, got %v", err)
+	}
+}
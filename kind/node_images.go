@@ -0,0 +1,189 @@
+package kind
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+	kindVersion "sigs.k8s.io/kind/pkg/cmd/kind/version"
+)
+
+// nodeImagesList is node_images.txt, the digest pinned node images published
+// with the kind release compiled into the provider, as listed in its release
+// notes. Regenerate it with `make update-node-images` whenever
+// sigs.k8s.io/kind is bumped.
+//
+//go:embed node_images.txt
+var nodeImagesList string
+
+// publishedNodeImages are the references of nodeImagesList, kind's default
+// node image is always part of them.
+var publishedNodeImages = parseNodeImageList(nodeImagesList)
+
+// parseNodeImageList returns the references of a node image list, one per
+// line, skipping blank lines and # comments.
+func parseNodeImageList(list string) []string {
+	refs := []string{kindDefaults.Image}
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || line == kindDefaults.Image {
+			continue
+		}
+		refs = append(refs, line)
+	}
+	return refs
+}
+
+// kubernetesVersionRegexp matches the versions accepted by kubernetes_version,
+// a minor version like 1.31 or a patch version like 1.31.2, optionally
+// prefixed with v.
+var kubernetesVersionRegexp = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?$`)
+
+// kindNodeImage is a node image published for a Kubernetes version.
+type kindNodeImage struct {
+	KubernetesVersion string
+	Image             string
+	Digest            string
+}
+
+// matches reports whether the image is published for a Kubernetes version,
+// the same patch version or any patch version of a minor version.
+func (img kindNodeImage) matches(version string) bool {
+	version = strings.TrimPrefix(version, "v")
+	return img.KubernetesVersion == version || strings.HasPrefix(img.KubernetesVersion, version+".")
+}
+
+// parseNodeImage splits a kindest/node:vX.Y.Z@sha256:... reference into the
+// Kubernetes version and digest.
+func parseNodeImage(ref string) (kindNodeImage, error) {
+	name, digest, _ := strings.Cut(ref, "@")
+	i := strings.LastIndex(name, ":")
+	if i < 0 || i < strings.LastIndex(name, "/") {
+		return kindNodeImage{}, fmt.Errorf("node image %q has no tag", ref)
+	}
+	version := strings.TrimPrefix(name[i+1:], "v")
+	if !kubernetesVersionRegexp.MatchString(version) {
+		return kindNodeImage{}, fmt.Errorf("node image %q is not tagged with a Kubernetes version", ref)
+	}
+	return kindNodeImage{KubernetesVersion: version, Image: ref, Digest: digest}, nil
+}
+
+// supportedNodeImages returns the published node images, newest Kubernetes
+// version first.
+func supportedNodeImages() []kindNodeImage {
+	seen := map[string]bool{}
+	images := []kindNodeImage{}
+	for _, ref := range publishedNodeImages {
+		img, err := parseNodeImage(ref)
+		if err != nil || seen[img.KubernetesVersion] {
+			continue
+		}
+		seen[img.KubernetesVersion] = true
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return compareVersions(images[i].KubernetesVersion, images[j].KubernetesVersion) > 0
+	})
+	return images
+}
+
+// resolveKubernetesVersion returns the published node image for a Kubernetes
+// version. A minor version resolves to its newest published patch version.
+func resolveKubernetesVersion(version string) (string, error) {
+	if !kubernetesVersionRegexp.MatchString(version) {
+		return "", fmt.Errorf("invalid kubernetes_version %q, expected a version like 1.31 or 1.31.2", version)
+	}
+	version = strings.TrimPrefix(version, "v")
+
+	images := supportedNodeImages()
+	supported := make([]string, 0, len(images))
+	for _, img := range images {
+		if img.matches(version) {
+			return img.Image, nil
+		}
+		supported = append(supported, img.KubernetesVersion)
+	}
+	return "", fmt.Errorf("kubernetes_version %q is not supported by kind v%s, supported versions: %s",
+		version, kindVersion.Version(), strings.Join(supported, ", "))
+}
+
+// compareVersions compares dotted numeric versions, returning a negative
+// number, zero or a positive number if a is older, equal or newer than b.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// imageIDRegexp matches a local image ID, like the image_id of
+// kind_node_image, which identifies an image by its content like a digest.
+var imageIDRegexp = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// imageHasDigest reports whether an image reference is pinned to a digest or
+// is an image ID.
+func imageHasDigest(ref string) bool {
+	return strings.Contains(ref, "@sha256:") || imageIDRegexp.MatchString(ref)
+}
+
+// imageDigest returns the digest of an image reference, from the reference
+// itself if pinned or an image ID, otherwise from the local container runtime.
+func imageDigest(ref string) (string, error) {
+	if _, digest, ok := strings.Cut(ref, "@"); ok {
+		return digest, nil
+	}
+	if imageIDRegexp.MatchString(ref) {
+		return ref, nil
+	}
+	return localImageDigest(ref)
+}
+
+// unpinnedNodeImages returns the node images of a kind_cluster that aren't
+// pinned to a digest, by attribute path.
+func unpinnedNodeImages(nodeImage string, nodes []interface{}) map[string]string {
+	unpinned := map[string]string{}
+	if nodeImage != "" && !imageHasDigest(nodeImage) {
+		unpinned["node_image"] = nodeImage
+	}
+	for i, n := range nodes {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if image, _ := node["image"].(string); image != "" && !imageHasDigest(image) {
+			unpinned[fmt.Sprintf("kind_config.0.node.%d.image", i)] = image
+		}
+	}
+	return unpinned
+}
+
+// requireImageDigest returns an error listing the node images of a
+// kind_cluster that aren't pinned to a digest, for require_image_digest.
+func requireImageDigest(nodeImage string, nodes []interface{}) error {
+	unpinned := unpinnedNodeImages(nodeImage, nodes)
+	keys := make([]string, 0, len(unpinned))
+	for k := range unpinned {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	errs := []error{}
+	for _, k := range keys {
+		errs = append(errs, fmt.Errorf("%s %q is not pinned to a digest, which is required by the provider's require_image_digest", k, unpinned[k]))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,305 @@
+package kind
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+)
+
+// unknownConfigValue is how the SDK represents a value unknown at plan time in
+// a raw resource config.
+const unknownConfigValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+func TestParseNodeImage(t *testing.T) {
+	img, err := parseNodeImage("kindest/node:v1.29.7@sha256:f70ab5d833fca132a100c1f95490be25d76188b053f49a3c0047ff8812360baf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.KubernetesVersion != "1.29.7" {
+		t.Errorf("expected Kubernetes version 1.29.7, got %q", img.KubernetesVersion)
+	}
+	if img.Digest != "sha256:f70ab5d833fca132a100c1f95490be25d76188b053f49a3c0047ff8812360baf" {
+		t.Errorf("unexpected digest %q", img.Digest)
+	}
+
+	for _, ref := range []string{"kindest/node", "localhost:5000/kindest/node", "kindest/node:latest"} {
+		if _, err := parseNodeImage(ref); err == nil {
+			t.Errorf("expected an error for %q", ref)
+		}
+	}
+}
+
+func TestResolveKubernetesVersion(t *testing.T) {
+	def, err := parseNodeImage(kindDefaults.Image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minor := def.KubernetesVersion[:strings.LastIndex(def.KubernetesVersion, ".")]
+
+	for _, version := range []string{def.KubernetesVersion, "v" + def.KubernetesVersion, minor} {
+		image, err := resolveKubernetesVersion(version)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", version, err)
+			continue
+		}
+		if image != kindDefaults.Image {
+			t.Errorf("expected %q to resolve to %q, got %q", version, kindDefaults.Image, image)
+		}
+	}
+
+	if _, err := resolveKubernetesVersion("1.1"); err == nil || !strings.Contains(err.Error(), def.KubernetesVersion) {
+		t.Errorf("expected an error listing the supported versions, got %v", err)
+	}
+	if _, err := resolveKubernetesVersion("latest"); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+}
+
+// testNodeImages are node images of several Kubernetes minor versions, the
+// digests are made up.
+var testNodeImages = []string{
+	"kindest/node:v1.36.1@sha256:" + strings.Repeat("6", 64),
+	"kindest/node:v1.35.4@sha256:" + strings.Repeat("5", 64),
+	"kindest/node:v1.34.7@sha256:" + strings.Repeat("4", 64),
+	"kindest/node:v1.33.10@sha256:" + strings.Repeat("3", 64),
+	"kindest/node:v1.31.12@sha256:" + strings.Repeat("1", 64),
+}
+
+// withNodeImages replaces the published node images for the duration of a
+// test.
+func withNodeImages(t *testing.T, images []string) {
+	published := publishedNodeImages
+	publishedNodeImages = images
+	t.Cleanup(func() { publishedNodeImages = published })
+}
+
+func TestResolveKubernetesVersion_Minors(t *testing.T) {
+	withNodeImages(t, testNodeImages)
+
+	cases := []struct {
+		Version  string
+		Expected string
+	}{
+		{"1.31", testNodeImages[4]},
+		{"v1.31", testNodeImages[4]},
+		{"1.31.12", testNodeImages[4]},
+		{"1.33", testNodeImages[3]},
+		{"1.34", testNodeImages[2]},
+		{"1.35.4", testNodeImages[1]},
+		{"1.36", testNodeImages[0]},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Version, func(t *testing.T) {
+			image, err := resolveKubernetesVersion(tc.Version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if image != tc.Expected {
+				t.Errorf("expected %q, got %q", tc.Expected, image)
+			}
+		})
+	}
+
+	for _, version := range []string{"1.32", "1.3", "1.31.11", "1.37"} {
+		_, err := resolveKubernetesVersion(version)
+		if err == nil || !strings.Contains(err.Error(), "1.36.1, 1.35.4, 1.34.7, 1.33.10, 1.31.12") {
+			t.Errorf("expected %q to be rejected with the supported versions, got %v", version, err)
+		}
+	}
+}
+
+func TestPublishedNodeImages(t *testing.T) {
+	if publishedNodeImages[0] != kindDefaults.Image {
+		t.Errorf("expected the default node image first, got %v", publishedNodeImages)
+	}
+	for _, ref := range publishedNodeImages {
+		img, err := parseNodeImage(ref)
+		if err != nil {
+			t.Errorf("node_images.txt: %s", err)
+			continue
+		}
+		if !imageHasDigest(img.Image) {
+			t.Errorf("node_images.txt: %q is not pinned to a digest", ref)
+		}
+	}
+
+	list := "# comment\n\n" + testNodeImages[4] + "\n  " + kindDefaults.Image + "\n"
+	if got := parseNodeImageList(list); !reflect.DeepEqual(got, []string{kindDefaults.Image, testNodeImages[4]}) {
+		t.Errorf("unexpected node images %v", got)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		A, B     string
+		Expected int
+	}{
+		{"1.31.2", "1.31.2", 0},
+		{"1.31.10", "1.31.2", 1},
+		{"1.30.9", "1.31.0", -1},
+	}
+	for _, tc := range cases {
+		got := compareVersions(tc.A, tc.B)
+		if (got > 0) != (tc.Expected > 0) || (got < 0) != (tc.Expected < 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, expected sign of %d", tc.A, tc.B, got, tc.Expected)
+		}
+	}
+}
+
+func TestResourceClusterKubernetesVersion(t *testing.T) {
+	def, err := parseNodeImage(kindDefaults.Image)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":               "test",
+		"kubernetes_version": def.KubernetesVersion,
+	})
+	diff, err := resourceCluster().Diff(context.Background(), nil, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := diff.Attributes["node_image"].New; got != kindDefaults.Image {
+		t.Errorf("expected node_image %q, got %q", kindDefaults.Image, got)
+	}
+
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":               "test",
+		"kubernetes_version": "1.1",
+	})
+	if _, err := resourceCluster().Diff(context.Background(), nil, config, nil); err == nil {
+		t.Error("expected an error for an unsupported kubernetes_version")
+	}
+}
+
+func TestResourceClusterKubernetesVersionConflicts(t *testing.T) {
+	raw := map[string]interface{}{
+		"name":               "test",
+		"kubernetes_version": "1.31",
+		"node_image":         nodeImage,
+	}
+	diags := resourceCluster().Validate(terraform.NewResourceConfigRaw(raw))
+	if !diags.HasError() {
+		t.Fatal("expected kubernetes_version to conflict with node_image")
+	}
+}
+
+func TestUnpinnedNodeImages(t *testing.T) {
+	nodes := []interface{}{
+		map[string]interface{}{"role": "control-plane", "image": nodeImage},
+		map[string]interface{}{"role": "worker", "image": "kindest/node:v1.29.7"},
+		map[string]interface{}{"role": "worker"},
+	}
+	expected := map[string]string{
+		"node_image":                 "kindest/node:latest",
+		"kind_config.0.node.1.image": "kindest/node:v1.29.7",
+	}
+	if got := unpinnedNodeImages("kindest/node:latest", nodes); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestResourceClusterRequireImageDigest(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Config      map[string]interface{}
+		Meta        interface{}
+		ExpectError bool
+	}{
+		{
+			Name:   "TagAllowedByDefault",
+			Config: map[string]interface{}{"name": "test", "node_image": "kindest/node:v1.29.7"},
+		},
+		{
+			Name:        "TagRejected",
+			Config:      map[string]interface{}{"name": "test", "node_image": "kindest/node:v1.29.7"},
+			Meta:        &providerConfig{RequireImageDigest: true},
+			ExpectError: true,
+		},
+		{
+			Name: "NodeTagRejected",
+			Config: map[string]interface{}{
+				"name": "test",
+				"kind_config": []interface{}{
+					map[string]interface{}{
+						"kind":        "Cluster",
+						"api_version": "kind.x-k8s.io/v1alpha4",
+						"node": []interface{}{
+							map[string]interface{}{"role": "control-plane", "image": "kindest/node:v1.29.7"},
+						},
+					},
+				},
+			},
+			Meta:        &providerConfig{RequireImageDigest: true},
+			ExpectError: true,
+		},
+		{
+			Name:   "DigestAccepted",
+			Config: map[string]interface{}{"name": "test", "node_image": nodeImage},
+			Meta:   &providerConfig{RequireImageDigest: true},
+		},
+		{
+			Name:   "DefaultImageAccepted",
+			Config: map[string]interface{}{"name": "test"},
+			Meta:   &providerConfig{RequireImageDigest: true},
+		},
+		{
+			Name:   "ImageIDAccepted",
+			Config: map[string]interface{}{"name": "test", "node_image": "sha256:" + strings.Repeat("a", 64)},
+			Meta:   &providerConfig{RequireImageDigest: true},
+		},
+		{
+			// e.g. the image_id of a kind_node_image built in the same apply,
+			// checked in Create instead
+			Name:   "UnknownImageDeferred",
+			Config: map[string]interface{}{"name": "test", "node_image": unknownConfigValue},
+			Meta:   &providerConfig{RequireImageDigest: true},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := resourceCluster().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(tc.Config), tc.Meta)
+			if (err != nil) != tc.ExpectError {
+				t.Errorf("expected error %t, got %v", tc.ExpectError, err)
+			}
+		})
+	}
+}
+
+func TestResourceClusterCreateRequireImageDigest(t *testing.T) {
+	// rejected before the cluster is created
+	t.Setenv("PATH", t.TempDir())
+	meta := &providerConfig{RequireImageDigest: true}
+	d := schema.TestResourceDataRaw(t, resourceCluster().Schema, map[string]interface{}{"name": "test", "node_image": "kindest/node:dev"})
+	diags := resourceKindClusterCreate(context.Background(), d, meta)
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "require_image_digest") {
+		t.Errorf("expected the unpinned node_image to be rejected, got %v", diags)
+	}
+}
+
+func TestResourceClusterNodeImageDigest(t *testing.T) {
+	cases := map[string]string{
+		nodeImage: "sha256:f70ab5d833fca132a100c1f95490be25d76188b053f49a3c0047ff8812360baf",
+		"":        kindDefaults.Image[strings.Index(kindDefaults.Image, "@")+1:],
+	}
+	for image, expected := range cases {
+		raw := map[string]interface{}{"name": "test"}
+		if image != "" {
+			raw["node_image"] = image
+		}
+		diff, err := resourceCluster().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := diff.Attributes["node_image_digest"].New; got != expected {
+			t.Errorf("expected node_image_digest %q for %q, got %q", expected, image, got)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package kind
 
 import (
+	"context"
+	"fmt"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 const (
@@ -12,11 +17,95 @@ const (
 	defaultDeleteTimeout = time.Minute * 5
 )
 
+// providerConfig holds the provider block settings, passed to the resources
+// as meta.
+type providerConfig struct {
+	RequireImageDigest bool
+	MaxParallelCreates int
+
+	// createSlots bounds concurrent cluster creation to MaxParallelCreates,
+	// nil if it isn't bounded.
+	createSlots chan struct{}
+}
+
+// acquireCreateSlot waits until fewer than max_parallel_creates clusters are
+// being created and returns a function to release the slot. It doesn't wait
+// without meta or a limit.
+func acquireCreateSlot(ctx context.Context, meta interface{}) (func(), error) {
+	config, ok := meta.(*providerConfig)
+	if !ok || config.createSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case config.createSlots <- struct{}{}:
+		return func() { <-config.createSlots }, nil
+	default:
+	}
+	tflog.Info(ctx, "Waiting for other clusters to be created", map[string]interface{}{"max_parallel_creates": config.MaxParallelCreates})
+	select {
+	case config.createSlots <- struct{}{}:
+		return func() { <-config.createSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for one of the %d max_parallel_creates slots: %w", config.MaxParallelCreates, ctx.Err())
+	}
+}
+
 func Provider() *schema.Provider {
 	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"require_image_digest": {
+				Type:        schema.TypeBool,
+				Description: "Reject node images that aren't pinned to a digest (image@sha256:...) or an image ID (sha256:...). Defaults to false, which only warns.",
+				Optional:    true,
+				Default:     false,
+			},
+			"preflight": {
+				Type:        schema.TypeBool,
+				Description: "Check the container runtime and host limits when the provider is configured and fail before creating anything if a check fails. The checks are the ones reported by the kind_host data source. Defaults to false.",
+				Optional:    true,
+				Default:     false,
+			},
+			"max_parallel_creates": {
+				Type:         schema.TypeInt,
+				Description:  "The maximum number of kind_cluster resources created at the same time, the others wait for a slot. Defaults to 0, which doesn't limit it. Independent of it, clusters are created one at a time until the shared kind network exists.",
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+		},
+		ConfigureContextFunc: providerConfigure,
+		DataSourcesMap: map[string]*schema.Resource{
+			"kind_cluster_logs": dataSourceClusterLogs(),
+		},
 		ResourcesMap: map[string]*schema.Resource{
-			"kind_cluster": resourceCluster(),
-			"kind_load":    resourceLoad(),
+			"kind_cluster":          resourceCluster(),
+			"kind_cluster_image":    resourceClusterImage(),
+			"kind_cluster_snapshot": resourceClusterSnapshot(),
+			"kind_load":             resourceLoad(),
+			"kind_manifest":         resourceManifest(),
+			"kind_node_image":       resourceNodeImage(),
+			"kind_registry":         resourceRegistry(),
 		},
 	}
 }
+
+// providerConfigure also runs the preflight checks. The framework provider
+// only accepts the preflight argument, so the mux server reports the checks
+// once.
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	config := &providerConfig{
+		RequireImageDigest: d.Get("require_image_digest").(bool),
+		MaxParallelCreates: d.Get("max_parallel_creates").(int),
+	}
+	if config.MaxParallelCreates > 0 {
+		config.createSlots = make(chan struct{}, config.MaxParallelCreates)
+	}
+	if d.Get("preflight").(bool) {
+		if diags := preflightDiagnostics(inspectHost().Checks); diags.HasError() {
+			return nil, diags
+		} else if len(diags) > 0 {
+			return config, diags
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,67 @@
+package kind
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestProviderServer(t *testing.T) {
+	server, err := testAccProtoV6ProviderFactories["kind"]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range resp.Diagnostics {
+		t.Errorf("unexpected diagnostic: %s: %s", d.Summary, d.Detail)
+	}
+	if _, ok := resp.ResourceSchemas["kind_cluster"]; !ok {
+		t.Error("expected kind_cluster from the sdk provider")
+	}
+	if _, ok := resp.DataSourceSchemas["kind_node_images"]; !ok {
+		t.Error("expected kind_node_images from the framework provider")
+	}
+	if _, ok := resp.EphemeralResourceSchemas["kind_cluster_credentials"]; !ok {
+		t.Error("expected kind_cluster_credentials from the framework provider")
+	}
+}
+
+// TestAccProviderServerStateCompatibility creates a cluster and loads an image
+// with the terraform-plugin-sdk provider on its own, as released before the
+// provider was combined with terraform-plugin-framework, and expects an empty
+// plan for the same state served by ProviderServer.
+func TestAccProviderServerStateCompatibility(t *testing.T) {
+	clusterName := acctest.RandomWithPrefix("tf-acc-mux-state-test")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			if out, err := exec.Command("docker", "pull", loadTestImage).CombinedOutput(); err != nil {
+				t.Fatalf("failed to pull test image %s: %s\n%s", loadTestImage, err, out)
+			}
+		},
+		CheckDestroy: testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				ProviderFactories: testAccProviderFactories,
+				Config:            testAccLoadConfig(clusterName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kind_cluster.test", "name", clusterName),
+					resource.TestCheckResourceAttr("kind_load.test", "cluster_name", clusterName),
+				),
+			},
+			{
+				ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+				Config:                   testAccLoadConfig(clusterName),
+				PlanOnly:                 true,
+			},
+		},
+	})
+}
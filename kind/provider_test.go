@@ -4,20 +4,34 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 var testAccProvider *schema.Provider
-var testAccProviders map[string]*schema.Provider
+
+// testAccProviderFactories serve the terraform-plugin-sdk provider on its own,
+// as released before the provider was combined with terraform-plugin-framework.
+var testAccProviderFactories map[string]func() (*schema.Provider, error)
 var testAccProviderFunc func() *schema.Provider
+var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"kind": func() (tfprotov6.ProviderServer, error) {
+		serverFactory, err := ProviderServer(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return serverFactory(), nil
+	},
+}
 
 func init() {
 	testAccProvider = Provider()
-	testAccProviders = map[string]*schema.Provider{
-		"kind": testAccProvider,
+	testAccProviderFactories = map[string]func() (*schema.Provider, error){
+		"kind": func() (*schema.Provider, error) { return Provider(), nil },
 	}
 	testAccProviderFunc = func() *schema.Provider { return testAccProvider }
 }
@@ -52,3 +66,34 @@ func testAccSimpleClusterProviderConfig() string {
 	provider "kind" {}
 `)
 }
+
+func TestAcquireCreateSlot(t *testing.T) {
+	ctx := context.Background()
+	if release, err := acquireCreateSlot(ctx, nil); err != nil {
+		t.Fatal(err)
+	} else {
+		release()
+	}
+
+	raw := map[string]interface{}{"max_parallel_creates": 1}
+	meta, diags := providerConfigure(ctx, schema.TestResourceDataRaw(t, Provider().Schema, raw))
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	release, err := acquireCreateSlot(ctx, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := acquireCreateSlot(timeout, meta); err == nil {
+		t.Error("expected to wait for the slot until the context is done")
+	}
+	release()
+	if release, err := acquireCreateSlot(ctx, meta); err != nil {
+		t.Errorf("expected the released slot to be available, got %s", err)
+	} else {
+		release()
+	}
+}
@@ -1,22 +1,30 @@
 package kind
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
+	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	clientcmd "k8s.io/client-go/tools/clientcmd"
+	kindDefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 	"sigs.k8s.io/kind/pkg/cluster"
-	"sigs.k8s.io/kind/pkg/cmd"
 )
 
 func resourceCluster() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceKindClusterCreate,
-		Read:   resourceKindClusterRead,
-		// Update: resourceKindClusterUpdate,
-		Delete: resourceKindClusterDelete,
+		CreateContext: resourceKindClusterCreate,
+		ReadContext:   resourceKindClusterRead,
+		UpdateContext: resourceKindClusterUpdate,
+		DeleteContext: resourceKindClusterDelete,
+
+		CustomizeDiff: resourceKindClusterCustomizeDiff,
 
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(defaultCreateTimeout),
@@ -32,11 +40,44 @@ func resourceCluster() *schema.Resource {
 				ForceNew:    true,
 			},
 			"node_image": {
+				Type:         schema.TypeString,
+				Description:  `The node_image that kind will use (ex: kindest/node:v1.29.7).`,
+				Optional:     true,
+				ForceNew:     true,
+				Computed:     true,
+				ValidateFunc: stringIsDigestPinnedImage,
+			},
+			"node_image_digest": {
+				Type:        schema.TypeString,
+				Description: `The digest of the node image, taken from node_image if pinned, otherwise resolved from the local container runtime.`,
+				Computed:    true,
+			},
+			"kubernetes_version": {
+				Type:          schema.TypeString,
+				Description:   `The Kubernetes version of the cluster (ex: 1.31 or 1.31.2). It resolves to the digest pinned node image published for the kind version of the provider, which is exported as node_image.`,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"node_image", "from_cluster_image"},
+				ValidateFunc:  validation.StringMatch(kubernetesVersionRegexp, "must be a Kubernetes version like 1.31 or 1.31.2"),
+			},
+			"node_image_archive": {
 				Type:        schema.TypeString,
-				Description: `The node_image that kind will use (ex: kindest/node:v1.29.7).`,
+				Description: `Path to a 'docker save' tarball containing the node images. It is verified against the node images, including pinned digests, and loaded into the container runtime before the cluster is created, so no registry access is needed.`,
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"from_cluster_image": {
+				Type:          schema.TypeString,
+				Description:   `Image repository of a kind_cluster_image to create the nodes from instead of bootstrapping a new cluster. The clone runs on its own network named kind-<name>.`,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"kind_config", "node_image", "kubernetes_version", "node_image_archive", "registries"},
+			},
+			"restore_from_snapshot": {
+				Type:        schema.TypeString,
+				Description: `Path to an etcd snapshot, e.g. from a kind_cluster_snapshot, restored right after the cluster is created. Only clusters with a single control plane node are supported.`,
 				Optional:    true,
 				ForceNew:    true,
-				Computed:    true,
 			},
 			"wait_for_ready": {
 				Type:        schema.TypeBool,
@@ -45,6 +86,27 @@ func resourceCluster() *schema.Resource {
 				ForceNew:    true, // TODO remove this once we have the update method defined.
 				Optional:    true,
 			},
+			"running": {
+				Type:        schema.TypeBool,
+				Description: `Whether the node containers of the cluster are running. Setting it to false stops the cluster without losing its state, setting it back to true starts it again. Defaults to true`,
+				Optional:    true,
+				Default:     true,
+				// state written before running existed belongs to a running
+				// cluster, Read records the actual value on refresh
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return d.Id() != "" && old == "" && new == "true"
+				},
+			},
+			"retain_on_failure": {
+				Type:        schema.TypeBool,
+				Description: `Keep the nodes when creating the cluster fails, for debugging. The cluster is then tainted and removed by the next apply or destroy. Defaults to false`,
+				Optional:    true,
+			},
+			"failure_logs_dir": {
+				Type:        schema.TypeString,
+				Description: `Directory the cluster logs are exported to (in a subdirectory named after the cluster) when creating the cluster fails.`,
+				Optional:    true,
+			},
 			"kind_config": {
 				Type:        schema.TypeList,
 				Description: `The kind_config that kind will use to bootstrap the cluster.`,
@@ -55,6 +117,22 @@ func resourceCluster() *schema.Resource {
 					Schema: kindConfigFields(),
 				},
 			},
+			"registries": {
+				Type:        schema.TypeList,
+				Description: `Names of kind_registry containers to wire into the cluster. Nodes pull localhost:<host_port> images from them and the local-registry-hosting ConfigMap advertises the first one.`,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"trusted_ca_certificates": {
+				Type:        schema.TypeList,
+				Description: `PEM encoded CA certificates installed into the trust store of every node, e.g. for TLS intercepting proxies. Changing them updates the nodes in place.`,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: stringIsPEMCertificate,
+				},
+			},
 			"kubeconfig_path": {
 				Type:        schema.TypeString,
 				Description: `Kubeconfig path set after the the cluster is created or by the user to override defaults.`,
@@ -66,16 +144,19 @@ func resourceCluster() *schema.Resource {
 				Type:        schema.TypeString,
 				Description: `Kubeconfig set after the the cluster is created.`,
 				Computed:    true,
+				Sensitive:   true,
 			},
 			"client_certificate": {
 				Type:        schema.TypeString,
 				Description: `Client certificate for authenticating to cluster.`,
 				Computed:    true,
+				Sensitive:   true,
 			},
 			"client_key": {
 				Type:        schema.TypeString,
 				Description: `Client key for authenticating to cluster.`,
 				Computed:    true,
+				Sensitive:   true,
 			},
 			"cluster_ca_certificate": {
 				Type:        schema.TypeString,
@@ -92,13 +173,164 @@ func resourceCluster() *schema.Resource {
 				Description: `Cluster successfully created.`,
 				Computed:    true,
 			},
+			"registry_mirrors_digest": {
+				Type:        schema.TypeString,
+				Description: `Digest of the registry mirror configuration found on the nodes. Nodes that lost or changed their configuration are repaired by the next apply.`,
+				Computed:    true,
+			},
 		},
 	}
 }
 
-func resourceKindClusterCreate(d *schema.ResourceData, meta interface{}) error {
-	log.Println("Creating local Kubernetes cluster...")
+// resourceKindClusterCustomizeDiff validates the kind_config at plan time
+// where it can't be done by the schema alone, resolves kubernetes_version to
+// a node image and recreates the cluster for node changes other than adding
+// or removing workers.
+func resourceKindClusterCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || d.HasChange("kubernetes_version") {
+		if !d.NewValueKnown("kubernetes_version") {
+			if err := d.SetNewComputed("node_image"); err != nil {
+				return err
+			}
+		} else if version := d.Get("kubernetes_version").(string); version != "" {
+			image, err := resolveKubernetesVersion(version)
+			if err != nil {
+				return err
+			}
+			if err := d.SetNew("node_image", image); err != nil {
+				return err
+			}
+		}
+	}
+
+	nodes := []interface{}{}
+	if cfg, ok := d.Get("kind_config").([]interface{}); ok && len(cfg) == 1 && cfg[0] != nil {
+		if n, ok := cfg[0].(map[string]interface{})["node"].([]interface{}); ok {
+			if err := validateIngressReadyNodes(n); err != nil {
+				return err
+			}
+			nodes = n
+		}
+	}
+
+	// images unknown at plan time, e.g. the image_id of a kind_node_image
+	// built in the same apply, read as empty and are checked in Create
+	if config, ok := meta.(*providerConfig); ok && config.RequireImageDigest {
+		if err := requireImageDigest(d.Get("node_image").(string), nodes); err != nil {
+			return err
+		}
+	}
+
+	if d.Get("from_cluster_image").(string) == "" && (d.Id() == "" || d.HasChange("node_image")) {
+		if err := customizeDiffNodeImageDigest(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	if err := customizeDiffRegistryMirrors(ctx, d); err != nil {
+		return err
+	}
+
+	if d.Id() != "" && d.HasChange("kind_config.0.node") {
+		oldConfig, newConfig := d.GetChange("kind_config")
+		if !workerScalingOnly(kindConfigNodeList(oldConfig.([]interface{})), kindConfigNodeList(newConfig.([]interface{}))) {
+			return forceNewChanges(d, "kind_config.0.node", kindConfigFields()["node"])
+		}
+	}
+	return nil
+}
+
+// forceNewChanges marks every changed attribute below key as requiring a new
+// resource. ForceNew on a list of blocks only applies to a change of its
+// length, so the blocks are walked down to their attributes.
+func forceNewChanges(d *schema.ResourceDiff, key string, s *schema.Schema) error {
+	if !d.HasChange(key) {
+		return nil
+	}
+	if err := d.ForceNew(key); err != nil {
+		return err
+	}
+	elem, ok := s.Elem.(*schema.Resource)
+	if !ok {
+		return nil
+	}
+	o, n := d.GetChange(key)
+	oldList, _ := o.([]interface{})
+	newList, _ := n.([]interface{})
+	for i := 0; i < max(len(oldList), len(newList)); i++ {
+		for name, field := range elem.Schema {
+			if err := forceNewChanges(d, fmt.Sprintf("%s.%d.%s", key, i, name), field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// customizeDiffRegistryMirrors plans an update of a running cluster whose
+// nodes don't have the configured registry mirror files, Update writes them.
+func customizeDiffRegistryMirrors(ctx context.Context, d *schema.ResourceDiff) error {
+	if d.Id() == "" || !d.Get("running").(bool) || d.HasChange("kind_config") {
+		return nil
+	}
+	mirrors := kindConfigRegistryMirrors(d.Get("kind_config"))
+	if len(mirrors) == 0 {
+		return nil
+	}
+	expected, err := expectedRegistryMirrorsDigest(mirrors)
+	if err != nil {
+		// the error is reported by Update if the nodes need to be repaired
+		tflog.Debug(ctx, "Unable to check registry mirrors for drift", map[string]interface{}{"error": err.Error()})
+		return nil
+	}
+	if d.Get("registry_mirrors_digest").(string) != expected {
+		return d.SetNew("registry_mirrors_digest", expected)
+	}
+	return nil
+}
+
+// customizeDiffNodeImageDigest records the digest of the planned node image.
+// Tags that aren't present in the local container runtime yet are resolved
+// once kind pulled the image during create.
+func customizeDiffNodeImageDigest(ctx context.Context, d *schema.ResourceDiff) error {
+	image := d.Get("node_image").(string)
+	if image == "" {
+		// node_image is computed when it isn't configured, it is only
+		// unknown if it is set from an unknown value
+		if raw := d.GetRawConfig(); !raw.IsNull() && raw.IsKnown() && !raw.GetAttr("node_image").IsKnown() {
+			return d.SetNewComputed("node_image_digest")
+		}
+		if !d.NewValueKnown("kubernetes_version") {
+			return d.SetNewComputed("node_image_digest")
+		}
+		image = kindDefaults.Image
+	}
+	digest, err := imageDigest(image)
+	if err != nil {
+		tflog.Debug(ctx, "Unable to resolve digest of node image at plan time", map[string]interface{}{"node_image": image, "error": err.Error()})
+		return d.SetNewComputed("node_image_digest")
+	}
+	return d.SetNew("node_image_digest", digest)
+}
+
+func resourceKindClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	name := d.Get("name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", name)
+
+	// node images unknown at plan time skipped the check in CustomizeDiff
+	if config, ok := meta.(*providerConfig); ok && config.RequireImageDigest {
+		if err := requireImageDigest(d.Get("node_image").(string), kindConfigNodeList(d.Get("kind_config").([]interface{}))); err != nil {
+			return errorDiagnostics(fmt.Sprintf("Unable to create kind cluster %q", name), err, nil)
+		}
+	}
+
+	release, err := acquireCreateSlot(ctx, meta)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to create kind cluster %q", name), err, nil)
+	}
+	defer release()
+
+	tflog.Info(ctx, "Creating local Kubernetes cluster")
 	nodeImage := d.Get("node_image").(string)
 	config := d.Get("kind_config")
 	waitForReady := d.Get("wait_for_ready").(bool)
@@ -113,62 +345,196 @@ func resourceKindClusterCreate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
+	var kindConfig *v1alpha4.Cluster
+	mirrors := []registryMirror{}
 	if config != nil {
 		cfg := config.([]interface{})
 		if len(cfg) == 1 { // there is always just one kind_config allowed
 			if data, ok := cfg[0].(map[string]interface{}); ok {
-				opts := flattenKindConfig(data)
-				copts = append(copts, cluster.CreateWithV1Alpha4Config(opts))
+				kindConfig = flattenKindConfig(data)
+				mirrors = flattenKindConfigRegistryMirrors(data)
 			}
 		}
 	}
 
-	if nodeImage != "" {
-		copts = append(copts, cluster.CreateWithNodeImage(nodeImage))
-		log.Printf("Using defined node_image: %s\n", nodeImage)
+	registries := expandStringList(d.Get("registries").([]interface{}))
+	if len(registries) > 0 {
+		if kindConfig == nil {
+			kindConfig = &v1alpha4.Cluster{}
+		}
+		appendContainerdConfigPathPatch(kindConfig)
+	}
+
+	if kindConfig != nil {
+		copts = append(copts, cluster.CreateWithV1Alpha4Config(kindConfig))
+	}
+
+	// the nodes are created from the loaded archive images, which may resolve
+	// by another reference than the node images
+	createImage := nodeImage
+	if archive := d.Get("node_image_archive").(string); archive != "" {
+		images := clusterNodeImages(nodeImage, kindConfig)
+		tflog.Info(ctx, "Loading node images from archive", map[string]interface{}{"node_images": images, "archive": archive})
+		refs, err := loadNodeImageArchive(archive, images)
+		if err != nil {
+			return errorDiagnostics("Unable to load node image archive", err, cty.GetAttrPath("node_image_archive"))
+		}
+		createImage = replaceNodeImages(nodeImage, kindConfig, refs)
+	}
+
+	if createImage != "" {
+		copts = append(copts, cluster.CreateWithNodeImage(createImage))
+		tflog.Debug(ctx, "Using defined node_image", map[string]interface{}{"node_image": createImage})
 	}
 
 	if waitForReady {
 		copts = append(copts, cluster.CreateWithWaitForReady(defaultCreateTimeout))
-		log.Printf("Will wait for cluster nodes to report ready: %t\n", waitForReady)
+		tflog.Debug(ctx, "Waiting for cluster nodes to report ready")
 	}
 
-	log.Println("=================== Creating Kind Cluster ==================")
-	provider := cluster.NewProvider(cluster.ProviderWithLogger(cmd.NewLogger()))
-	err := provider.Create(name, copts...)
-	if err != nil {
-		return err
+	// nodes have to be kept around on failure to collect their logs
+	retainOnFailure := d.Get("retain_on_failure").(bool)
+	failureLogsDir := d.Get("failure_logs_dir").(string)
+	if retainOnFailure || failureLogsDir != "" {
+		copts = append(copts, cluster.CreateWithRetain(true))
 	}
 
+	provider := newKindProvider(ctx)
+	if fromImage := d.Get("from_cluster_image").(string); fromImage != "" {
+		tflog.Info(ctx, "Creating cluster from images", map[string]interface{}{"from_cluster_image": fromImage})
+		path, _ := kubeconfigPath.(string)
+		err = createClusterFromImages(ctx, provider, name, fromImage, path, retainOnFailure || failureLogsDir != "", d.Timeout(schema.TimeoutCreate))
+		if err != nil && !retainOnFailure && failureLogsDir != "" {
+			// handleCreateFailure deletes the nodes but not the clone's network
+			defer func() {
+				if err := removeClusterImageNetwork(name); err != nil {
+					tflog.Warn(ctx, "Unable to remove network of cloned cluster", map[string]interface{}{"error": err.Error()})
+				}
+			}()
+		}
+	} else {
+		var unlockNetwork func()
+		unlockNetwork, err = acquireKindNetwork(ctx)
+		if err != nil {
+			return errorDiagnostics(fmt.Sprintf("Unable to create kind cluster %q", name), err, nil)
+		}
+		err = provider.Create(name, copts...)
+		unlockNetwork()
+		if err != nil && strings.Contains(err.Error(), kubeconfigLockedError) {
+			// the cluster is up, only the kubeconfig export lost the race
+			// for the lock file against another cluster
+			path, _ := kubeconfigPath.(string)
+			err = withKubeconfigLock(ctx, path, func() error {
+				return provider.ExportKubeConfig(name, path, false)
+			})
+		}
+	}
+	if err != nil {
+		if !retainOnFailure && failureLogsDir == "" {
+			return hostPortErrorDiagnostics(fmt.Sprintf("Unable to create kind cluster %q", name), err, config.([]interface{}))
+		}
+		if retainOnFailure {
+			// let Terraform track the retained nodes so they get cleaned up
+			d.SetId(fmt.Sprintf("%s-%s", name, nodeImage))
+		}
+		path, _ := kubeconfigPath.(string)
+		return hostPortErrorDiagnostics(fmt.Sprintf("Unable to create kind cluster %q", name), handleCreateFailure(provider, name, path, failureLogsDir, retainOnFailure, err), config.([]interface{}))
+	}
 	d.SetId(fmt.Sprintf("%s-%s", name, nodeImage))
-	return resourceKindClusterRead(d, meta)
+
+	// tags that weren't present locally at plan time were pulled by kind
+	if d.Get("from_cluster_image").(string) == "" && d.Get("node_image_digest").(string) == "" {
+		image := nodeImage
+		if image == "" {
+			image = kindDefaults.Image
+		}
+		if digest, err := imageDigest(image); err == nil {
+			d.Set("node_image_digest", digest)
+		} else {
+			tflog.Warn(ctx, "Unable to resolve digest of node image", map[string]interface{}{"node_image": image, "error": err.Error()})
+		}
+	}
+
+	// restore first, everything the provider configures in the API would be
+	// lost otherwise
+	if snapshot := d.Get("restore_from_snapshot").(string); snapshot != "" {
+		tflog.Info(ctx, "Restoring etcd snapshot", map[string]interface{}{"snapshot": snapshot})
+		if err := singleControlPlane(provider, name); err != nil {
+			return errorDiagnostics("Unable to restore etcd snapshot", err, cty.GetAttrPath("restore_from_snapshot"))
+		}
+		node, err := etcdControlPlaneNode(provider, name)
+		if err != nil {
+			return errorDiagnostics("Unable to restore etcd snapshot", err, cty.GetAttrPath("restore_from_snapshot"))
+		}
+		if err := restoreEtcdSnapshot(ctx, node, snapshot, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return errorDiagnostics("Unable to restore etcd snapshot", err, cty.GetAttrPath("restore_from_snapshot"))
+		}
+	}
+
+	if err := configureLocalRegistries(provider, name, registries); err != nil {
+		return errorDiagnostics("Unable to configure local registries", err, cty.GetAttrPath("registries"))
+	}
+
+	if certificates := expandStringList(d.Get("trusted_ca_certificates").([]interface{})); len(certificates) > 0 {
+		if err := installTrustedCACertificates(provider, name, certificates); err != nil {
+			return errorDiagnostics("Unable to install trusted CA certificates", err, cty.GetAttrPath("trusted_ca_certificates"))
+		}
+	}
+
+	if len(mirrors) > 0 {
+		nodeList, err := provider.ListInternalNodes(name)
+		if err != nil {
+			return errorDiagnostics("Unable to list nodes of kind cluster", err, nil)
+		}
+		if err := applyRegistryMirrors(nodeList, mirrors); err != nil {
+			return errorDiagnostics("Unable to configure registry mirrors", err, kindConfigRegistryMirrorPath)
+		}
+	}
+
+	if !d.Get("running").(bool) {
+		tflog.Info(ctx, "Stopping cluster")
+		if err := stopCluster(ctx, provider, name); err != nil {
+			return errorDiagnostics("Unable to stop kind cluster", err, cty.GetAttrPath("running"))
+		}
+	}
+
+	return resourceKindClusterRead(ctx, d, meta)
 }
 
-func resourceKindClusterRead(d *schema.ResourceData, meta interface{}) error {
+func resourceKindClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	name := d.Get("name").(string)
-	provider := cluster.NewProvider(cluster.ProviderWithLogger(cmd.NewLogger()))
-	id := d.Id()
-	log.Printf("ID: %s\n", id)
+	ctx = tflog.SetField(ctx, "cluster_name", name)
+	provider := newKindProvider(ctx)
+
+	// a stopped cluster can't be inspected, keep the last known state
+	running, err := clusterRunning(provider, name)
+	if err == nil && !running {
+		d.Set("running", false)
+		return nil
+	}
+	d.Set("running", true)
 
 	kconfig, err := provider.KubeConfig(name, false)
 	if err != nil {
 		d.SetId("")
-		return err
+		return errorDiagnostics("Unable to read kubeconfig of kind cluster", err, nil)
 	}
 	d.Set("kubeconfig", kconfig)
 
 	currentPath, err := os.Getwd()
 	if err != nil {
 		d.SetId("")
-		return err
+		return errorDiagnostics("Unable to determine the working directory", err, nil)
 	}
 
 	if _, ok := d.GetOk("kubeconfig_path"); !ok {
 		exportPath := fmt.Sprintf("%s%s%s-config", currentPath, string(os.PathSeparator), name)
-		err = provider.ExportKubeConfig(name, exportPath, false)
+		err = withKubeconfigLock(ctx, exportPath, func() error {
+			return provider.ExportKubeConfig(name, exportPath, false)
+		})
 		if err != nil {
 			d.SetId("")
-			return err
+			return errorDiagnostics("Unable to export kubeconfig of kind cluster", err, cty.GetAttrPath("kubeconfig_path"))
 		}
 		d.Set("kubeconfig_path", exportPath)
 	}
@@ -176,7 +542,7 @@ func resourceKindClusterRead(d *schema.ResourceData, meta interface{}) error {
 	// use the current context in kubeconfig
 	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kconfig))
 	if err != nil {
-		return err
+		return errorDiagnostics("Unable to parse kubeconfig of kind cluster", err, cty.GetAttrPath("kubeconfig"))
 	}
 
 	d.Set("client_certificate", string(config.CertData))
@@ -184,21 +550,121 @@ func resourceKindClusterRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("cluster_ca_certificate", string(config.CAData))
 	d.Set("endpoint", string(config.Host))
 
+	// containerd configuration for registry mirrors lives on the nodes,
+	// record what is there so drift, e.g. after node containers were
+	// recreated, is repaired by the next apply
+	if mirrors := kindConfigRegistryMirrors(d.Get("kind_config")); len(mirrors) > 0 {
+		nodeList, err := provider.ListInternalNodes(name)
+		if err != nil {
+			return errorDiagnostics("Unable to list nodes of kind cluster", err, nil)
+		}
+		d.Set("registry_mirrors_digest", nodesRegistryMirrorsDigest(nodeList, mirrors))
+	} else {
+		d.Set("registry_mirrors_digest", "")
+	}
+
 	d.Set("completed", true)
 
 	return nil
 }
 
-func resourceKindClusterDelete(d *schema.ResourceData, meta interface{}) error {
-	log.Println("Deleting local Kubernetes cluster...")
+func resourceKindClusterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	name := d.Get("name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", name)
+	provider := newKindProvider(ctx)
+	running := d.Get("running").(bool)
+
+	if d.HasChange("running") && running {
+		tflog.Info(ctx, "Starting cluster")
+		if err := startCluster(ctx, provider, name, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return errorDiagnostics("Unable to start kind cluster", err, cty.GetAttrPath("running"))
+		}
+		// the API server port may have changed with the restart
+		kubeconfigPath := d.Get("kubeconfig_path").(string)
+		if err := withKubeconfigLock(ctx, kubeconfigPath, func() error {
+			return provider.ExportKubeConfig(name, kubeconfigPath, false)
+		}); err != nil {
+			return errorDiagnostics("Unable to export kubeconfig of kind cluster", err, cty.GetAttrPath("kubeconfig_path"))
+		}
+	}
+
+	if d.HasChange("kind_config.0.node") {
+		if !running {
+			return diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "Cluster must be running to scale worker nodes",
+				Detail:        fmt.Sprintf("Worker nodes of cluster %q can only be added or removed while it is running. Set running = true, apply, and scale the workers afterwards.", name),
+				AttributePath: cty.GetAttrPath("kind_config").IndexInt(0).GetAttr("node"),
+			}}
+		}
+		tflog.Info(ctx, "Scaling worker nodes")
+		oldConfig, newConfig := d.GetChange("kind_config")
+		certificates := expandStringList(d.Get("trusted_ca_certificates").([]interface{}))
+		if err := scaleWorkers(ctx, provider, name, d.Get("node_image").(string), oldConfig.([]interface{}), newConfig.([]interface{}), certificates); err != nil {
+			return hostPortErrorDiagnostics("Unable to scale worker nodes", err, newConfig.([]interface{}))
+		}
+	}
+
+	// new workers and nodes that lost their containerd configuration get the
+	// registry mirror files, nodes that have them are left alone
+	if running && (d.HasChange("registry_mirrors_digest") || d.HasChange("kind_config.0.node")) {
+		if mirrors := kindConfigRegistryMirrors(d.Get("kind_config")); len(mirrors) > 0 {
+			tflog.Info(ctx, "Updating registry mirrors")
+			nodeList, err := provider.ListInternalNodes(name)
+			if err != nil {
+				return errorDiagnostics("Unable to list nodes of kind cluster", err, nil)
+			}
+			if err := applyRegistryMirrors(nodeList, mirrors); err != nil {
+				return errorDiagnostics("Unable to configure registry mirrors", err, kindConfigRegistryMirrorPath)
+			}
+		}
+	}
+
+	if d.HasChange("trusted_ca_certificates") {
+		if !running {
+			return diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "Cluster must be running to update trusted CA certificates",
+				Detail:        fmt.Sprintf("The trusted CA certificates of cluster %q can only be updated while it is running. Set running = true, apply, and update the certificates afterwards.", name),
+				AttributePath: cty.GetAttrPath("trusted_ca_certificates"),
+			}}
+		}
+		tflog.Info(ctx, "Updating trusted CA certificates")
+		certificates := expandStringList(d.Get("trusted_ca_certificates").([]interface{}))
+		if err := installTrustedCACertificates(provider, name, certificates); err != nil {
+			return errorDiagnostics("Unable to install trusted CA certificates", err, cty.GetAttrPath("trusted_ca_certificates"))
+		}
+	}
+
+	if d.HasChange("running") && !running {
+		tflog.Info(ctx, "Stopping cluster")
+		if err := stopCluster(ctx, provider, name); err != nil {
+			return errorDiagnostics("Unable to stop kind cluster", err, cty.GetAttrPath("running"))
+		}
+	}
+
+	return resourceKindClusterRead(ctx, d, meta)
+}
+
+func resourceKindClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	name := d.Get("name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", name)
 	kubeconfigPath := d.Get("kubeconfig_path").(string)
-	provider := cluster.NewProvider(cluster.ProviderWithLogger(cmd.NewLogger()))
+	provider := newKindProvider(ctx)
 
-	log.Println("=================== Deleting Kind Cluster ==================")
-	err := provider.Delete(name, kubeconfigPath)
+	tflog.Info(ctx, "Deleting local Kubernetes cluster")
+	err := withKubeconfigLock(ctx, kubeconfigPath, func() error {
+		return provider.Delete(name, kubeconfigPath)
+	})
 	if err != nil {
-		return err
+		return errorDiagnostics(fmt.Sprintf("Unable to delete kind cluster %q", name), err, nil)
+	}
+
+	var diags diag.Diagnostics
+	if d.Get("from_cluster_image").(string) != "" {
+		if err := removeClusterImageNetwork(name); err != nil {
+			diags = append(diags, warningDiagnostic("Unable to remove network of cloned cluster", err))
+		}
 	}
 
 	// Remove kubeconfig context, user, and cluster from default kubeconfig
@@ -208,27 +674,48 @@ func resourceKindClusterDelete(d *schema.ResourceData, meta interface{}) error {
 
 	// Clean up default kubeconfig
 	defaultKubeconfigPath := clientcmd.RecommendedHomeFile
-	removeKubeContext(defaultKubeconfigPath, contextName, "default")
+	if err := withKubeconfigLock(ctx, defaultKubeconfigPath, func() error {
+		return removeKubeContext(defaultKubeconfigPath, contextName, "default")
+	}); err != nil {
+		diags = append(diags, warningDiagnostic("Unable to remove cluster context from kubeconfig", err))
+	}
 
 	// Clean up custom kubeconfig if specified
 	if kubeconfigPath != "" {
-		removeKubeContext(kubeconfigPath, contextName, "custom")
+		if err := withKubeconfigLock(ctx, kubeconfigPath, func() error {
+			return removeKubeContext(kubeconfigPath, contextName, "custom")
+		}); err != nil {
+			diags = append(diags, warningDiagnostic("Unable to remove cluster context from kubeconfig", err))
+		}
 	}
 
 	d.SetId("")
-	return nil
+	return diags
 }
 
-// removeKubeContext removes a context, cluster, and user entry from a kubeconfig file.
-func removeKubeContext(configPath, contextName, configType string) {
+// removeKubeContext removes a context, cluster, and user entry from a
+// kubeconfig file while holding its lock file. A kubeconfig that doesn't exist
+// has nothing to remove.
+func removeKubeContext(configPath, contextName, configType string) error {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil
+	}
+	unlock, err := lockKubeconfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	config, err := clientcmd.LoadFromFile(configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		log.Printf("Warning: Unable to load %s kubeconfig for context cleanup: %v", configType, err)
-		return
+		return fmt.Errorf("unable to load %s kubeconfig %s for context cleanup: %s", configType, configPath, err)
 	}
 
 	if _, exists := config.Contexts[contextName]; !exists {
-		return
+		return nil
 	}
 
 	delete(config.Contexts, contextName)
@@ -240,6 +727,7 @@ func removeKubeContext(configPath, contextName, configType string) {
 	}
 
 	if err := clientcmd.WriteToFile(*config, configPath); err != nil {
-		log.Printf("Warning: Unable to write %s kubeconfig to remove context: %v", configType, err)
+		return fmt.Errorf("unable to write %s kubeconfig %s to remove context %q: %s", configType, configPath, contextName, err)
 	}
+	return nil
 }
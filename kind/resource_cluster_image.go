@@ -0,0 +1,97 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+func resourceClusterImage() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceKindClusterImageCreate,
+		ReadContext:   resourceKindClusterImageRead,
+		DeleteContext: resourceKindClusterImageDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultCreateTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"cluster_name": {
+				Type:        schema.TypeString,
+				Description: "The name of the kind cluster to commit. Only clusters with a single control plane node are supported.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"repository": {
+				Type:        schema.TypeString,
+				Description: "The image repository the nodes are committed to, each node is tagged with its name without the cluster name, e.g. 'control-plane' or 'worker2'.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Description: "Arbitrary values that, when changed, commit the cluster again.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"images": {
+				Type:        schema.TypeMap,
+				Description: "The committed node images, image ID by image reference.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceKindClusterImageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	clusterName := d.Get("cluster_name").(string)
+	repository := d.Get("repository").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+	tflog.Info(ctx, "Committing cluster nodes", map[string]interface{}{"repository": repository})
+
+	provider := newKindProvider(ctx)
+	images, err := commitClusterImages(ctx, provider, clusterName, repository)
+	if len(images) > 0 {
+		// keep track of partially committed images so they get cleaned up
+		d.SetId(clusterName + "|" + repository)
+		d.Set("images", images)
+	}
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to commit nodes of kind cluster %q", clusterName), err, cty.GetAttrPath("cluster_name"))
+	}
+	return resourceKindClusterImageRead(ctx, d, meta)
+}
+
+func resourceKindClusterImageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	images := d.Get("images").(map[string]interface{})
+	for ref, id := range images {
+		current, err := localImageID(ref)
+		if err != nil || current != id.(string) {
+			tflog.Info(ctx, "Image is missing or was replaced, removing kind_cluster_image from state", map[string]interface{}{"image": ref})
+			d.SetId("")
+			return nil
+		}
+	}
+	return nil
+}
+
+func resourceKindClusterImageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// The images may still be used by clusters, failing to remove them is not an error.
+	var diags diag.Diagnostics
+	for ref := range d.Get("images").(map[string]interface{}) {
+		tflog.Info(ctx, "Removing image", map[string]interface{}{"image": ref})
+		if lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "image", "rm", ref)); err != nil {
+			diags = append(diags, warningDiagnostic(fmt.Sprintf("Unable to remove image %s", ref), fmt.Errorf("%s: %v", err, lines)))
+		}
+	}
+	d.SetId("")
+	return diags
+}
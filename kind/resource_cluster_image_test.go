@@ -0,0 +1,114 @@
+package kind
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestClusterImageTag(t *testing.T) {
+	cases := map[string]string{
+		"test-control-plane": "control-plane",
+		"test-worker":        "worker",
+		"test-worker2":       "worker2",
+	}
+	for node, expected := range cases {
+		if got := clusterImageTag("test", node); got != expected {
+			t.Errorf("expected tag %q for node %q, got %q", expected, node, got)
+		}
+	}
+}
+
+func TestResourceClusterFromClusterImageConflicts(t *testing.T) {
+	raw := map[string]interface{}{
+		"name":               "test",
+		"from_cluster_image": "kind-clones/test",
+		"node_image":         nodeImage,
+	}
+	diags := resourceCluster().Validate(terraform.NewResourceConfigRaw(raw))
+	if !diags.HasError() {
+		t.Fatal("expected from_cluster_image to conflict with node_image")
+	}
+}
+
+func TestAccClusterImageClone(t *testing.T) {
+	sourceName := acctest.RandomWithPrefix("tf-acc-image-source")
+	cloneName := acctest.RandomWithPrefix("tf-acc-image-clone")
+	repository := fmt.Sprintf("tf-acc-kind-clones/%s", sourceName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			testAccCheckKindClusterResourceDestroy(sourceName),
+			testAccCheckKindClusterResourceDestroy(cloneName),
+		),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterImageConfig(sourceName, repository),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kind_cluster_image.test", "images.%", "2"),
+					resource.TestCheckResourceAttrSet("kind_cluster_image.test", fmt.Sprintf("images.%s:control-plane", repository)),
+				),
+			},
+			{
+				Config: testAccClusterImageConfig(sourceName, repository) + testAccClusterFromImageConfig(cloneName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckClusterCreate("kind_cluster.clone"),
+					resource.TestCheckResourceAttrSet("kind_cluster.clone", "endpoint"),
+					testAccCheckClusterNodeCount(cloneName, 2),
+					testAccCheckConfigMapExists(cloneName, "default", "seeded"),
+				),
+			},
+		},
+	})
+}
+
+func testAccClusterImageConfig(sourceName, repository string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "source" {
+  name           = "%s"
+  wait_for_ready = true
+  kind_config {
+    kind        = "Cluster"
+    api_version = "kind.x-k8s.io/v1alpha4"
+
+    node {
+      role = "control-plane"
+    }
+
+    node {
+      role = "worker"
+    }
+  }
+}
+
+resource "kind_manifest" "seed" {
+  cluster_name = kind_cluster.source.name
+  content      = <<-YAML
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: seeded
+      namespace: default
+  YAML
+}
+
+resource "kind_cluster_image" "test" {
+  cluster_name = kind_manifest.seed.cluster_name
+  repository   = "%s"
+}
+`, sourceName, repository)
+}
+
+func testAccClusterFromImageConfig(cloneName string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "clone" {
+  name               = "%s"
+  from_cluster_image = kind_cluster_image.test.repository
+}
+`, cloneName)
+}
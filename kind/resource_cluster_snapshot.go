@@ -0,0 +1,125 @@
+package kind
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceClusterSnapshot() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceKindClusterSnapshotCreate,
+		ReadContext:   resourceKindClusterSnapshotRead,
+		DeleteContext: resourceKindClusterSnapshotDelete,
+
+		Schema: map[string]*schema.Schema{
+			"cluster_name": {
+				Type:        schema.TypeString,
+				Description: "The name of the kind cluster to snapshot.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"path": {
+				Type:        schema.TypeString,
+				Description: "The host path the etcd snapshot is written to.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Description: "Arbitrary values that, when changed, take a new snapshot.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"sha256": {
+				Type:        schema.TypeString,
+				Description: "The SHA-256 checksum of the snapshot file.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceKindClusterSnapshotCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	clusterName := d.Get("cluster_name").(string)
+	path, err := filepath.Abs(d.Get("path").(string))
+	if err != nil {
+		return errorDiagnostics("Invalid snapshot path", err, cty.GetAttrPath("path"))
+	}
+
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+	tflog.Info(ctx, "Saving etcd snapshot", map[string]interface{}{"path": path})
+	provider := newKindProvider(ctx)
+	node, err := etcdControlPlaneNode(provider, clusterName)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to find the etcd node of kind cluster %q", clusterName), err, cty.GetAttrPath("cluster_name"))
+	}
+	if err := saveEtcdSnapshot(node, path); err != nil {
+		return errorDiagnostics("Unable to save etcd snapshot", err, cty.GetAttrPath("path"))
+	}
+
+	d.SetId(clusterName + "|" + path)
+	return resourceKindClusterSnapshotRead(ctx, d, meta)
+}
+
+func resourceKindClusterSnapshotRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	path, err := filepath.Abs(d.Get("path").(string))
+	if err != nil {
+		return errorDiagnostics("Invalid snapshot path", err, cty.GetAttrPath("path"))
+	}
+
+	sum, err := fileSHA256(path)
+	if os.IsNotExist(err) {
+		tflog.Info(ctx, "Snapshot not found, removing kind_cluster_snapshot from state", map[string]interface{}{"path": path})
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return errorDiagnostics("Unable to read etcd snapshot", err, cty.GetAttrPath("path"))
+	}
+
+	if previous := d.Get("sha256").(string); previous != "" && previous != sum {
+		// the file was replaced outside of Terraform, take the snapshot again
+		tflog.Info(ctx, "Snapshot was modified, removing kind_cluster_snapshot from state", map[string]interface{}{"path": path})
+		d.SetId("")
+		return nil
+	}
+	d.Set("sha256", sum)
+	return nil
+}
+
+func resourceKindClusterSnapshotDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	path, err := filepath.Abs(d.Get("path").(string))
+	if err != nil {
+		return errorDiagnostics("Invalid snapshot path", err, cty.GetAttrPath("path"))
+	}
+	tflog.Info(ctx, "Removing etcd snapshot", map[string]interface{}{"path": path})
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errorDiagnostics("Unable to remove etcd snapshot", err, cty.GetAttrPath("path"))
+	}
+	d.SetId("")
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
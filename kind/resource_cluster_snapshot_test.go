@@ -0,0 +1,149 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceClusterSnapshotRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := os.WriteFile(path, []byte("snapshot"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name         string
+		Path         string
+		SHA256       string
+		ExpectRemove bool
+	}{
+		{Name: "Unchanged", Path: path, SHA256: sum},
+		{Name: "Missing", Path: path + ".missing", SHA256: sum, ExpectRemove: true},
+		{Name: "Modified", Path: path, SHA256: "0000", ExpectRemove: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			d := resourceClusterSnapshot().TestResourceData()
+			d.SetId("test|" + tc.Path)
+			d.Set("cluster_name", "test")
+			d.Set("path", tc.Path)
+			d.Set("sha256", tc.SHA256)
+
+			if diags := resourceKindClusterSnapshotRead(context.Background(), d, nil); diags.HasError() {
+				t.Fatal(diags)
+			}
+			if removed := d.Id() == ""; removed != tc.ExpectRemove {
+				t.Errorf("expected removed %t, got %t", tc.ExpectRemove, removed)
+			}
+		})
+	}
+}
+
+func TestAccClusterSnapshotRestore(t *testing.T) {
+	clusterName := acctest.RandomWithPrefix("tf-acc-snapshot")
+	snapshot := filepath.Join(t.TempDir(), "etcd.db")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterSnapshotConfig(clusterName, snapshot),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("kind_cluster_snapshot.test", "sha256"),
+					testAccCheckFileExists(snapshot),
+				),
+			},
+			{
+				// recreate the cluster from the snapshot, the ConfigMap applied
+				// before the snapshot was taken comes back with it
+				Config: testAccClusterRestoreConfig(clusterName, snapshot),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("kind_cluster.test", "restore_from_snapshot", snapshot),
+					testAccCheckConfigMapExists(clusterName, "default", "seeded"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckFileExists(path string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		_, err := os.Stat(path)
+		return err
+	}
+}
+
+func testAccCheckConfigMapExists(clusterName, namespace, name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client, err := manifestClientForCluster(context.Background(), clusterName)
+		if err != nil {
+			return err
+		}
+		exists, err := client.exists(context.Background(), manifestObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: namespace, Name: name})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("ConfigMap %s/%s not found in cluster %q", namespace, name, clusterName)
+		}
+		return nil
+	}
+}
+
+func testAccClusterSnapshotConfig(clusterName, snapshot string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name           = "%s"
+  wait_for_ready = true
+}
+
+resource "kind_manifest" "seed" {
+  cluster_name = kind_cluster.test.name
+  content      = <<-YAML
+    apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: seeded
+      namespace: default
+    data:
+      seeded: "true"
+  YAML
+}
+
+resource "kind_cluster_snapshot" "test" {
+  cluster_name = kind_manifest.seed.cluster_name
+  path         = "%s"
+}
+`, clusterName, snapshot)
+}
+
+func testAccClusterRestoreConfig(clusterName, snapshot string) string {
+	// restore_from_snapshot replaces the cluster the snapshot was taken from,
+	// the snapshot itself only tracks the file on the host
+	return fmt.Sprintf(`
+resource "kind_cluster_snapshot" "test" {
+  cluster_name = "%[1]s"
+  path         = "%[2]s"
+}
+
+resource "kind_cluster" "test" {
+  name                  = "%[1]s"
+  wait_for_ready        = true
+  restore_from_snapshot = kind_cluster_snapshot.test.path
+}
+`, clusterName, snapshot)
+}
@@ -1,8 +1,12 @@
 package kind
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
@@ -91,7 +95,9 @@ preferences: {}
 	// Before the fix this panics with:
 	//   panic: runtime error: invalid memory address or nil pointer dereference
 	//   github.com/modern-go/reflect2.(*UnsafeMapIterator).HasNext
-	removeKubeContext(tmpFile.Name(), "kind-test", "test")
+	if err := removeKubeContext(tmpFile.Name(), "kind-test", "test"); err != nil {
+		t.Fatal(err)
+	}
 
 	// Verify the context was removed and the file is still valid
 	config, err := clientcmd.LoadFromFile(tmpFile.Name())
@@ -124,14 +130,78 @@ preferences: {}
 	}
 }
 
+// TestResourceClusterDiff_StateUpgrade makes sure state written by a provider
+// version before attributes with defaults were added plans without changes,
+// which would update or replace clusters nobody touched.
+func TestResourceClusterDiff_StateUpgrade(t *testing.T) {
+	state := &terraform.InstanceState{ID: "test-", Attributes: map[string]string{
+		"id":                        "test-",
+		"name":                      "test",
+		"wait_for_ready":            "false",
+		"completed":                 "true",
+		"kind_config.#":             "1",
+		"kind_config.0.kind":        "Cluster",
+		"kind_config.0.api_version": "kind.x-k8s.io/v1alpha4",
+		"kind_config.0.node.#":      "2",
+		"kind_config.0.node.0.role": "control-plane",
+		"kind_config.0.node.1.role": "worker",
+		"kind_config.0.node.1.extra_port_mappings.#":                "1",
+		"kind_config.0.node.1.extra_port_mappings.0.container_port": "30000",
+		"kind_config.0.node.1.extra_port_mappings.0.host_port":      "30000",
+	}}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name": "test",
+		"kind_config": []interface{}{
+			map[string]interface{}{
+				"kind":        "Cluster",
+				"api_version": "kind.x-k8s.io/v1alpha4",
+				"node": []interface{}{
+					map[string]interface{}{"role": "control-plane"},
+					map[string]interface{}{
+						"role":                "worker",
+						"extra_port_mappings": []interface{}{map[string]interface{}{"container_port": 30000, "host_port": 30000}},
+					},
+				},
+			},
+		},
+	})
+
+	diff, err := resourceCluster().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && !diff.Empty() {
+		for k, attr := range diff.Attributes {
+			t.Errorf("unexpected diff for %s: %q => %q", k, attr.Old, attr.New)
+		}
+	}
+
+	// running still defaults to true for new and stopped clusters
+	diff, err = resourceCluster().Diff(context.Background(), nil, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := diff.Attributes["running"]; got == nil || got.New != "true" {
+		t.Errorf("expected a new cluster to be running, got %#v", got)
+	}
+	state.Attributes["running"] = "false"
+	diff, err = resourceCluster().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := diff.Attributes["running"]; got == nil || got.New != "true" {
+		t.Errorf("expected a stopped cluster to be started, got %#v", got)
+	}
+}
+
 func TestAccCluster(t *testing.T) {
 	resourceName := "kind_cluster.test"
 	clusterName := acctest.RandomWithPrefix("tf-acc-cluster-test")
 
 	resource.ParallelTest(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckKindClusterResourceDestroy(clusterName),
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
 		Steps: []resource.TestStep{
 			{
 				Config: testAccBasicClusterConfig(clusterName),
@@ -199,9 +269,9 @@ func TestAccClusterConfigBase(t *testing.T) {
 	clusterName := acctest.RandomWithPrefix("tf-acc-config-base-test")
 
 	resource.ParallelTest(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckKindClusterResourceDestroy(clusterName),
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
 		Steps: []resource.TestStep{
 			{
 				Config: testAccClusterConfigAndExtra(clusterName),
@@ -316,9 +386,9 @@ func TestAccClusterConfigNodes(t *testing.T) {
 	clusterName := acctest.RandomWithPrefix("tf-acc-config-nodes-test")
 
 	resource.ParallelTest(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckKindClusterResourceDestroy(clusterName),
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
 		Steps: []resource.TestStep{
 			{
 				Config: testAccBasicExtraConfigClusterConfig(clusterName),
@@ -423,9 +493,9 @@ func TestAccClusterContainerdPatches(t *testing.T) {
 	clusterName := acctest.RandomWithPrefix("tf-acc-containerd-test")
 
 	resource.ParallelTest(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckKindClusterResourceDestroy(clusterName),
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
 		Steps: []resource.TestStep{
 			{
 				Config: testSingleContainerdConfigPatch(clusterName),
@@ -449,9 +519,9 @@ func TestAccContainerdPatchFormatOnlyChangeIsNoop(t *testing.T) {
 	clusterName := acctest.RandomWithPrefix("tf-acc-containerd-formatting")
 
 	resource.ParallelTest(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckKindClusterResourceDestroy(clusterName),
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
 		Steps: []resource.TestStep{
 			{
 				Config: testTwoContainerdConfigPatches(clusterName),
@@ -474,6 +544,234 @@ func TestAccContainerdPatchFormatOnlyChangeIsNoop(t *testing.T) {
 	})
 }
 
+func TestAccClusterRegistryMirror(t *testing.T) {
+	resourceName := "kind_cluster.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-registry-mirror")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfigRegistryMirror(clusterName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckClusterCreate(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "kind_config.0.registry_mirror.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "kind_config.0.registry_mirror.0.registry", "docker.io"),
+					resource.TestCheckResourceAttr(resourceName, "kind_config.0.registry_mirror.0.endpoints.0", "https://mirror.gcr.io"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccClusterTrustedCACertificates(t *testing.T) {
+	resourceName := "kind_cluster.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-trusted-ca")
+	firstCA := testGenerateCACertificate(t, "first-ca")
+	secondCA := testGenerateCACertificate(t, "second-ca")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfigTrustedCACertificates(clusterName, firstCA),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckClusterCreate(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "trusted_ca_certificates.#", "1"),
+				),
+			},
+			{
+				// changing the certificates updates the nodes in place
+				Config: testAccClusterConfigTrustedCACertificates(clusterName, secondCA),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "trusted_ca_certificates.0", secondCA),
+				),
+			},
+		},
+	})
+}
+
+func TestAccClusterNodeImageArchive(t *testing.T) {
+	resourceName := "kind_cluster.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-node-image-archive")
+	archive := filepath.Join(t.TempDir(), "node-image.tar")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			// saved by tag, docker's classic image store doesn't record the
+			// tag of an image saved by digest
+			tag, _, _ := strings.Cut(nodeImage, "@")
+			for _, c := range [][]string{
+				{"docker", "pull", nodeImage},
+				{"docker", "tag", nodeImage, tag},
+				{"docker", "save", "-o", archive, tag},
+			} {
+				if out, err := exec.Command(c[0], c[1:]...).CombinedOutput(); err != nil {
+					t.Fatalf("failed to run %v: %s\n%s", c, err, out)
+				}
+			}
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfigNodeImageArchive(clusterName, archive),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckClusterCreate(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "node_image", nodeImage),
+					resource.TestCheckResourceAttr(resourceName, "node_image_archive", archive),
+				),
+			},
+		},
+	})
+}
+
+func TestAccClusterStopStart(t *testing.T) {
+	resourceName := "kind_cluster.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-stop-start")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfigRunning(clusterName, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckClusterCreate(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "running", "true"),
+				),
+			},
+			{
+				// stopping keeps the cluster, Read must not drop it from state
+				Config: testAccClusterConfigRunning(clusterName, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "running", "false"),
+					testAccCheckClusterRunning(clusterName, false),
+				),
+			},
+			{
+				Config: testAccClusterConfigRunning(clusterName, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "running", "true"),
+					resource.TestCheckResourceAttrSet(resourceName, "endpoint"),
+					testAccCheckClusterRunning(clusterName, true),
+				),
+			},
+		},
+	})
+}
+
+func TestAccClusterScaleWorkers(t *testing.T) {
+	resourceName := "kind_cluster.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-scale-workers")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfigWorkers(clusterName, 1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckClusterCreate(resourceName),
+					testAccCheckClusterNodeCount(clusterName, 2),
+				),
+			},
+			{
+				// adding a worker keeps the cluster
+				Config: testAccClusterConfigWorkers(clusterName, 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "kind_config.0.node.#", "3"),
+					testAccCheckClusterNodeCount(clusterName, 3),
+				),
+			},
+			{
+				Config: testAccClusterConfigWorkers(clusterName, 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "kind_config.0.node.#", "2"),
+					testAccCheckClusterNodeCount(clusterName, 2),
+				),
+			},
+		},
+	})
+}
+
+func TestAccClusterParallelCreates(t *testing.T) {
+	clusterName := acctest.RandomWithPrefix("tf-acc-parallel")
+	names := []string{clusterName + "-0", clusterName + "-1", clusterName + "-2"}
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			testAccCheckKindClusterResourceDestroy(names[0]),
+			testAccCheckKindClusterResourceDestroy(names[1]),
+			testAccCheckKindClusterResourceDestroy(names[2]),
+		),
+		Steps: []resource.TestStep{
+			{
+				// all clusters export their kubeconfig to the default one
+				Config: testAccClusterConfigParallel(clusterName, 2),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckClusterCreate("kind_cluster.test.0"),
+					testAccCheckClusterCreate("kind_cluster.test.1"),
+					testAccCheckClusterCreate("kind_cluster.test.2"),
+					testAccCheckKubeconfigContexts(names),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckKubeconfigContexts(clusterNames []string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config, err := clientcmd.NewDefaultPathOptions().GetStartingConfig()
+		if err != nil {
+			return err
+		}
+		for _, name := range clusterNames {
+			if _, exists := config.Contexts["kind-"+name]; !exists {
+				return fmt.Errorf("kubeconfig context kind-%s should exist", name)
+			}
+		}
+		return nil
+	}
+}
+
+func testAccCheckClusterNodeCount(clusterName string, expected int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		prov := cluster.NewProvider()
+		nodeList, err := prov.ListNodes(clusterName)
+		if err != nil {
+			return err
+		}
+		if len(nodeList) != expected {
+			return fmt.Errorf("expected %d nodes in cluster %q, got %d", expected, clusterName, len(nodeList))
+		}
+		return nil
+	}
+}
+
+func testAccCheckClusterRunning(clusterName string, expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		prov := cluster.NewProvider()
+		running, err := clusterRunning(prov, clusterName)
+		if err != nil {
+			return err
+		}
+		if running != expected {
+			return fmt.Errorf("expected cluster %q running to be %t, got %t", clusterName, expected, running)
+		}
+		return nil
+	}
+}
+
 // testAccCheckKindClusterResourceDestroy verifies the kind cluster
 // has been destroyed
 func testAccCheckKindClusterResourceDestroy(clusterName string) resource.TestCheckFunc {
@@ -906,3 +1204,154 @@ resource "kind_cluster" "test" {
 }
 `, name)
 }
+
+func testAccClusterConfigRegistryMirror(name string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name = "%s"
+  wait_for_ready = true
+  kind_config {
+	kind = "Cluster"
+	api_version = "kind.x-k8s.io/v1alpha4"
+
+	registry_mirror {
+		registry  = "docker.io"
+		endpoints = ["https://mirror.gcr.io"]
+	}
+  }
+}
+`, name)
+}
+
+func testAccClusterConfigTrustedCACertificates(name, certificate string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name = "%s"
+  trusted_ca_certificates = [
+	<<-PEM
+%sPEM
+  ]
+}
+`, name, certificate)
+}
+
+func testAccClusterConfigNodeImageArchive(name, archive string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name               = "%s"
+  node_image         = "%s"
+  node_image_archive = "%s"
+}
+`, name, nodeImage, archive)
+}
+
+func testAccClusterConfigRunning(name string, running bool) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name    = "%s"
+  running = %t
+}
+`, name, running)
+}
+
+func testAccClusterConfigParallel(name string, maxParallelCreates int) string {
+	return fmt.Sprintf(`
+provider "kind" {
+  max_parallel_creates = %d
+}
+
+resource "kind_cluster" "test" {
+  count = 3
+  name  = "%s-${count.index}"
+}
+`, maxParallelCreates, name)
+}
+
+func testAccClusterConfigWorkers(name string, workers int) string {
+	nodes := ""
+	for i := 0; i < workers; i++ {
+		nodes += `
+    node {
+      role = "worker"
+    }
+`
+	}
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name           = "%s"
+  wait_for_ready = true
+  kind_config {
+    kind        = "Cluster"
+    api_version = "kind.x-k8s.io/v1alpha4"
+
+    node {
+      role = "control-plane"
+    }
+%s
+  }
+}
+`, name, nodes)
+}
+
+// TestResourceClusterDiff_RegistryMirrorsDrift makes sure nodes that lost or
+// changed their registry mirror files plan an in place update repairing them.
+func TestResourceClusterDiff_RegistryMirrorsDrift(t *testing.T) {
+	mirror := map[string]interface{}{
+		"registry":  "docker.io",
+		"endpoints": []interface{}{"https://mirror.example.com"},
+	}
+	expected, err := expectedRegistryMirrorsDigest(flattenKindConfigRegistryMirrors(map[string]interface{}{
+		"registry_mirror": []interface{}{mirror},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name": "test",
+		"kind_config": []interface{}{
+			map[string]interface{}{
+				"kind":            "Cluster",
+				"api_version":     "kind.x-k8s.io/v1alpha4",
+				"registry_mirror": []interface{}{mirror},
+			},
+		},
+	})
+	stateWithDigest := func(digest string) *terraform.InstanceState {
+		return &terraform.InstanceState{ID: "test-", Attributes: map[string]string{
+			"id":                              "test-",
+			"name":                            "test",
+			"running":                         "true",
+			"wait_for_ready":                  "false",
+			"completed":                       "true",
+			"registry_mirrors_digest":         digest,
+			"kind_config.#":                   "1",
+			"kind_config.0.kind":              "Cluster",
+			"kind_config.0.api_version":       "kind.x-k8s.io/v1alpha4",
+			"kind_config.0.registry_mirror.#": "1",
+			"kind_config.0.registry_mirror.0.registry":    "docker.io",
+			"kind_config.0.registry_mirror.0.endpoints.#": "1",
+			"kind_config.0.registry_mirror.0.endpoints.0": "https://mirror.example.com",
+		}}
+	}
+
+	diff, err := resourceCluster().Diff(context.Background(), stateWithDigest(expected), config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && !diff.Empty() {
+		for k, attr := range diff.Attributes {
+			t.Errorf("unexpected diff for %s: %q => %q", k, attr.Old, attr.New)
+		}
+	}
+
+	diff, err = resourceCluster().Diff(context.Background(), stateWithDigest(""), config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || diff.RequiresNew() {
+		t.Fatalf("expected an in place update, got %#v", diff)
+	}
+	if got := diff.Attributes["registry_mirrors_digest"]; got == nil || got.New != expected {
+		t.Errorf("expected registry_mirrors_digest to change to %s, got %#v", expected, got)
+	}
+}
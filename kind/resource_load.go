@@ -1,15 +1,19 @@
 package kind
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"sigs.k8s.io/kind/pkg/cluster"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"sigs.k8s.io/kind/pkg/cluster/nodes"
 	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
-	"sigs.k8s.io/kind/pkg/cmd"
 	"sigs.k8s.io/kind/pkg/errors"
 	"sigs.k8s.io/kind/pkg/exec"
 	"sigs.k8s.io/kind/pkg/fs"
@@ -17,14 +21,19 @@ import (
 
 func resourceLoad() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceKindLoadCreate,
-		Read:   resourceKindLoadRead,
-		Delete: resourceKindLoadDelete,
+		CreateContext: resourceKindLoadCreate,
+		ReadContext:   resourceKindLoadRead,
+		UpdateContext: resourceKindLoadUpdate,
+		DeleteContext: resourceKindLoadDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceKindLoadImport,
+		},
+		CustomizeDiff: resourceKindLoadCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"image": {
 				Type:        schema.TypeString,
-				Description: "The Docker image name to load into the kind cluster (e.g. 'alpine', 'myapp:latest'). Must be present in the local Docker daemon.",
+				Description: "The Docker image name to load into the kind cluster (e.g. 'alpine', 'myapp:latest'). Must be present in the local Docker daemon unless `pull_policy` allows pulling it.",
 				Required:    true,
 				ForceNew:    true,
 			},
@@ -34,43 +43,63 @@ func resourceLoad() *schema.Resource {
 				Required:    true,
 				ForceNew:    true,
 			},
+			"pull_policy": {
+				Type:         schema.TypeString,
+				Description:  "Whether to pull the image into the local container runtime before loading it. One of 'never', 'if_not_present' or 'always'. Defaults to 'never'. Only used when the image is loaded, changing it doesn't load the image again.",
+				Optional:     true,
+				Default:      pullPolicyNever,
+				ValidateFunc: validation.StringInSlice([]string{pullPolicyNever, pullPolicyIfNotPresent, pullPolicyAlways}, false),
+			},
+			"node_status": {
+				Type:        schema.TypeMap,
+				Description: "Map of cluster node names to the ID of the image present on that node. Nodes missing the image map to an empty string and cause the image to be loaded again.",
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
 		},
 	}
 }
 
-func resourceKindLoadCreate(d *schema.ResourceData, meta interface{}) error {
+func resourceKindLoadCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	imageName := d.Get("image").(string)
 	clusterName := d.Get("cluster_name").(string)
+	pullPolicy := d.Get("pull_policy").(string)
 
-	log.Printf("Loading image %q into kind cluster %q...", imageName, clusterName)
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+	tflog.Info(ctx, "Loading image into cluster", map[string]interface{}{"image": imageName})
 
-	// Verify the image exists locally in Docker and get its ID
-	imageID, err := dockerImageID(imageName)
+	// Make sure the image exists locally, pulling it if allowed, and get its ID
+	imageID, err := ensureImage(imageName, pullPolicy)
 	if err != nil {
-		return fmt.Errorf("image %q not present locally: %s", imageName, err)
+		return errorDiagnostics(fmt.Sprintf("Image %q is not available", imageName), err, cty.GetAttrPath("image"))
 	}
 
 	// Get cluster nodes
-	provider := cluster.NewProvider(cluster.ProviderWithLogger(cmd.NewLogger()))
+	provider := newKindProvider(ctx)
 	nodeList, err := provider.ListInternalNodes(clusterName)
 	if err != nil {
-		return fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+		return errorDiagnostics(fmt.Sprintf("Unable to list nodes of kind cluster %q", clusterName), err, cty.GetAttrPath("cluster_name"))
 	}
 	if len(nodeList) == 0 {
-		return fmt.Errorf("no nodes found for cluster %q", clusterName)
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("Kind cluster %q has no nodes", clusterName),
+			Detail:        "No nodes were found for the cluster. Make sure the cluster exists, `kind get clusters` lists the clusters kind knows about.",
+			AttributePath: cty.GetAttrPath("cluster_name"),
+		}}
 	}
 
 	// Save the image to a temp tar archive
 	dir, err := fs.TempDir("", "kind-load")
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %s", err)
+		return errorDiagnostics("Unable to create temp directory", err, nil)
 	}
 	defer os.RemoveAll(dir)
 
 	imagesTarPath := filepath.Join(dir, "images.tar")
-	err = exec.Command("docker", "save", "-o", imagesTarPath, imageName).Run()
+	err = exec.Command(containerRuntime(), "save", "-o", imagesTarPath, imageName).Run()
 	if err != nil {
-		return fmt.Errorf("failed to save image %q: %s", imageName, err)
+		return errorDiagnostics(fmt.Sprintf("Unable to save image %q", imageName), err, cty.GetAttrPath("image"))
 	}
 
 	// Load the image onto all nodes concurrently
@@ -87,57 +116,121 @@ func resourceKindLoadCreate(d *schema.ResourceData, meta interface{}) error {
 		})
 	}
 	if err := errors.UntilErrorConcurrent(fns); err != nil {
-		return fmt.Errorf("failed to load image onto nodes: %s", err)
+		return errorDiagnostics(fmt.Sprintf("Unable to load image %q onto the cluster nodes", imageName), err, nil)
 	}
 
 	d.SetId(clusterName + "|" + imageID)
-	log.Printf("Successfully loaded image %q into cluster %q", imageName, clusterName)
-	return nil
-}
-
-// dockerImageID returns the Docker image ID for a given image name.
-func dockerImageID(imageName string) (string, error) {
-	lines, err := exec.OutputLines(
-		exec.Command("docker", "image", "inspect", "-f", "{{ .Id }}", imageName),
-	)
-	if err != nil {
-		return "", err
-	}
-	if len(lines) != 1 {
-		return "", fmt.Errorf("expected 1 line of output, got %d", len(lines))
-	}
-	return lines[0], nil
+	tflog.Info(ctx, "Loaded image into cluster", map[string]interface{}{"image": imageName, "image_id": imageID})
+	return resourceKindLoadRead(ctx, d, meta)
 }
 
-func resourceKindLoadRead(d *schema.ResourceData, meta interface{}) error {
+func resourceKindLoadRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	imageName := d.Get("image").(string)
 	clusterName := d.Get("cluster_name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
 
-	provider := cluster.NewProvider(cluster.ProviderWithLogger(cmd.NewLogger()))
+	provider := newKindProvider(ctx)
 
 	// Check if the cluster still exists
 	nodeList, err := provider.ListInternalNodes(clusterName)
 	if err != nil || len(nodeList) == 0 {
-		log.Printf("Cluster %q not found or has no nodes, removing kind_load from state", clusterName)
+		tflog.Info(ctx, "Cluster not found or has no nodes, removing kind_load from state")
+		d.SetId("")
+		return nil
+	}
+
+	// Record which nodes have the image, it needs to be present on at least one node
+	nodeStatus := imageNodeStatus(nodeList, imageName)
+	found := false
+	for _, id := range nodeStatus {
+		if id != "" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		tflog.Info(ctx, "Image not found on any node, removing kind_load from state", map[string]interface{}{"image": imageName})
 		d.SetId("")
 		return nil
 	}
 
-	// Check if the image is present on at least one node
+	d.Set("node_status", nodeStatus)
+	return nil
+}
+
+// imageNodeStatus returns a map of node name to the ID of the given image on
+// that node, or an empty string if the node doesn't have the image.
+func imageNodeStatus(nodeList []nodes.Node, imageName string) map[string]string {
+	status := make(map[string]string, len(nodeList))
 	for _, node := range nodeList {
 		id, err := nodeutils.ImageID(node, imageName)
-		if err == nil && id != "" {
-			return nil
+		if err != nil {
+			id = ""
 		}
+		status[node.String()] = id
 	}
+	return status
+}
 
-	// Image not found on any node
-	log.Printf("Image %q not found on any node in cluster %q, removing from state", imageName, clusterName)
-	d.SetId("")
+// resourceKindLoadCustomizeDiff forces the image to be loaded again when it
+// is missing from some of the cluster nodes, e.g. after nodes were added.
+func resourceKindLoadCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+	for node, id := range d.Get("node_status").(map[string]interface{}) {
+		if id.(string) == "" {
+			tflog.Info(ctx, "Image missing on node, it will be loaded again", map[string]interface{}{"image": d.Get("image").(string), "node": node})
+			if err := d.SetNewComputed("node_status"); err != nil {
+				return err
+			}
+			return d.ForceNew("node_status")
+		}
+	}
 	return nil
 }
 
-func resourceKindLoadDelete(d *schema.ResourceData, meta interface{}) error {
+// resourceKindLoadImport imports a kind_load using an ID of the form
+// <cluster>|<image> and resolves the image ID from the cluster nodes.
+func resourceKindLoadImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(d.Id(), "|", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("unexpected import ID %q, expected <cluster>|<image>", d.Id())
+	}
+	clusterName, imageName := parts[0], parts[1]
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+
+	provider := newKindProvider(ctx)
+	nodeList, err := provider.ListInternalNodes(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+
+	imageID := ""
+	for _, id := range imageNodeStatus(nodeList, imageName) {
+		if id != "" {
+			imageID = id
+			break
+		}
+	}
+	if imageID == "" {
+		return nil, fmt.Errorf("image %q not found on any node of cluster %q", imageName, clusterName)
+	}
+
+	d.Set("cluster_name", clusterName)
+	d.Set("image", imageName)
+	d.Set("pull_policy", pullPolicyNever)
+	d.SetId(clusterName + "|" + imageID)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceKindLoadUpdate only records pull_policy, which is used when the
+// image is loaded.
+func resourceKindLoadUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceKindLoadRead(ctx, d, meta)
+}
+
+func resourceKindLoadDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	d.SetId("")
 	return nil
 }
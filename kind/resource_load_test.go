@@ -1,12 +1,14 @@
 package kind
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const loadTestImage = "busybox:1.36"
@@ -14,15 +16,18 @@ const loadTestImage = "busybox:1.36"
 func TestResourceLoadSchema(t *testing.T) {
 	r := resourceLoad()
 
-	if r.Create == nil {
+	if r.CreateContext == nil {
 		t.Error("Create function should not be nil")
 	}
-	if r.Read == nil {
+	if r.ReadContext == nil {
 		t.Error("Read function should not be nil")
 	}
-	if r.Delete == nil {
+	if r.DeleteContext == nil {
 		t.Error("Delete function should not be nil")
 	}
+	if r.Importer == nil {
+		t.Error("Importer should not be nil")
+	}
 
 	fields := r.Schema
 	if _, ok := fields["image"]; !ok {
@@ -44,6 +49,99 @@ func TestResourceLoadSchema(t *testing.T) {
 	if !fields["cluster_name"].ForceNew {
 		t.Error("'cluster_name' should be ForceNew")
 	}
+	if !fields["pull_policy"].Optional {
+		t.Error("'pull_policy' should be Optional")
+	}
+	if fields["pull_policy"].Default != pullPolicyNever {
+		t.Errorf("'pull_policy' should default to %q", pullPolicyNever)
+	}
+	if fields["pull_policy"].ForceNew {
+		t.Error("'pull_policy' should not be ForceNew")
+	}
+	if !fields["node_status"].Computed {
+		t.Error("'node_status' should be Computed")
+	}
+}
+
+func TestResourceLoadImport_InvalidID(t *testing.T) {
+	for _, id := range []string{"", "cluster", "cluster|", "|alpine"} {
+		d := resourceLoad().TestResourceData()
+		d.SetId(id)
+		if _, err := resourceKindLoadImport(context.Background(), d, nil); err == nil {
+			t.Errorf("expected error for import ID %q", id)
+		}
+	}
+}
+
+// TestResourceLoadDiff_PullPolicyUpgrade makes sure state written before
+// pull_policy existed isn't replaced, which would load every image again.
+func TestResourceLoadDiff_PullPolicyUpgrade(t *testing.T) {
+	state := &terraform.InstanceState{ID: "test|sha256:abc", Attributes: map[string]string{
+		"id":                             "test|sha256:abc",
+		"image":                          "alpine",
+		"cluster_name":                   "test",
+		"node_status.%":                  "1",
+		"node_status.test-control-plane": "sha256:abc",
+	}}
+	for _, policy := range []string{"", pullPolicyAlways} {
+		raw := map[string]interface{}{"image": "alpine", "cluster_name": "test"}
+		if policy != "" {
+			raw["pull_policy"] = policy
+		}
+		diff, err := resourceLoad().Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff != nil && diff.RequiresNew() {
+			t.Errorf("expected pull_policy %q to update in place, got %#v", policy, diff.Attributes)
+		}
+	}
+}
+
+func TestResourceLoadCustomizeDiff_MissingNode(t *testing.T) {
+	cases := []struct {
+		Name              string
+		NodeStatus        map[string]string
+		ExpectRequiresNew bool
+	}{
+		{
+			Name:       "LoadedOnAllNodes",
+			NodeStatus: map[string]string{"test-control-plane": "sha256:abc", "test-worker": "sha256:abc"},
+		},
+		{
+			Name:              "MissingOnNewNode",
+			NodeStatus:        map[string]string{"test-control-plane": "sha256:abc", "test-worker": ""},
+			ExpectRequiresNew: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			attributes := map[string]string{
+				"id":            "test|sha256:abc",
+				"image":         "alpine",
+				"cluster_name":  "test",
+				"pull_policy":   pullPolicyNever,
+				"node_status.%": fmt.Sprintf("%d", len(tc.NodeStatus)),
+			}
+			for node, id := range tc.NodeStatus {
+				attributes["node_status."+node] = id
+			}
+			state := &terraform.InstanceState{ID: "test|sha256:abc", Attributes: attributes}
+			config := terraform.NewResourceConfigRaw(map[string]interface{}{
+				"image":        "alpine",
+				"cluster_name": "test",
+			})
+
+			diff, err := resourceLoad().Diff(context.Background(), state, config, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := diff != nil && diff.RequiresNew(); got != tc.ExpectRequiresNew {
+				t.Errorf("expected RequiresNew %t, got %t", tc.ExpectRequiresNew, got)
+			}
+		})
+	}
 }
 
 func TestResourceLoadCreate_InvalidInputs(t *testing.T) {
@@ -52,8 +150,8 @@ func TestResourceLoadCreate_InvalidInputs(t *testing.T) {
 	d.Set("image", "alpine")
 	d.Set("cluster_name", "nonexistent-cluster-xyz")
 
-	err := resourceKindLoadCreate(d, nil)
-	if err == nil {
+	diags := resourceKindLoadCreate(context.Background(), d, nil)
+	if !diags.HasError() {
 		t.Fatal("expected error for nonexistent cluster")
 	}
 }
@@ -65,9 +163,9 @@ func TestResourceLoadRead_ClusterGone(t *testing.T) {
 	d.Set("image", "alpine")
 	d.Set("cluster_name", "nonexistent-cluster")
 
-	err := resourceKindLoadRead(d, nil)
-	if err != nil {
-		t.Fatalf("Read should not error when cluster is gone, got: %v", err)
+	diags := resourceKindLoadRead(context.Background(), d, nil)
+	if diags.HasError() {
+		t.Fatalf("Read should not error when cluster is gone, got: %v", diags)
 	}
 	if d.Id() != "" {
 		t.Error("ID should be cleared when cluster is gone")
@@ -86,14 +184,65 @@ func TestAccLoad(t *testing.T) {
 				t.Fatalf("failed to pull test image %s: %s\n%s", loadTestImage, err, out)
 			}
 		},
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCheckKindClusterResourceDestroy(clusterName),
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
 		Steps: []resource.TestStep{
 			{
 				Config: testAccLoadConfig(clusterName),
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttr(resourceName, "image", loadTestImage),
 					resource.TestCheckResourceAttr(resourceName, "cluster_name", clusterName),
+					resource.TestCheckResourceAttr(resourceName, "node_status.%", "1"),
+					resource.TestCheckResourceAttrSet(resourceName, "node_status."+clusterName+"-control-plane"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateId:     clusterName + "|" + loadTestImage,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccLoadPullFromRegistry(t *testing.T) {
+	resourceName := "kind_load.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-load-pull-test")
+	registryName := acctest.RandomWithPrefix("tf-acc-load-registry")
+	registryPort := acctest.RandIntRange(20000, 30000)
+	registryImage := fmt.Sprintf("localhost:%d/busybox:1.36", registryPort)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			// Push the test image into a throwaway local registry and remove it
+			// from the daemon, so the provider has to pull it back.
+			cmds := [][]string{
+				{"docker", "pull", loadTestImage},
+				{"docker", "run", "-d", "--name", registryName, "-p", fmt.Sprintf("127.0.0.1:%d:5000", registryPort), "registry:2"},
+				{"docker", "tag", loadTestImage, registryImage},
+				{"docker", "push", registryImage},
+				{"docker", "image", "rm", registryImage},
+			}
+			for _, c := range cmds {
+				if out, err := exec.Command(c[0], c[1:]...).CombinedOutput(); err != nil {
+					t.Fatalf("failed to run %v: %s\n%s", c, err, out)
+				}
+			}
+			t.Cleanup(func() {
+				exec.Command("docker", "rm", "-f", registryName).Run()
+				exec.Command("docker", "image", "rm", registryImage).Run()
+			})
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccLoadPullConfig(clusterName, registryImage),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "image", registryImage),
+					resource.TestCheckResourceAttr(resourceName, "pull_policy", "if_not_present"),
 				),
 			},
 		},
@@ -113,3 +262,18 @@ resource "kind_load" "test" {
 }
 `, clusterName, loadTestImage)
 }
+
+func testAccLoadPullConfig(clusterName, image string) string {
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name           = "%s"
+  wait_for_ready = true
+}
+
+resource "kind_load" "test" {
+  image        = "%s"
+  cluster_name = kind_cluster.test.name
+  pull_policy  = "if_not_present"
+}
+`, clusterName, image)
+}
@@ -0,0 +1,377 @@
+package kind
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const defaultFieldManager = "terraform-provider-kind"
+
+func resourceManifest() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceKindManifestCreate,
+		ReadContext:   resourceKindManifestRead,
+		UpdateContext: resourceKindManifestUpdate,
+		DeleteContext: resourceKindManifestDelete,
+
+		CustomizeDiff: resourceKindManifestCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"cluster_name": {
+				Type:        schema.TypeString,
+				Description: "The name of the kind cluster to apply the manifest to.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"content": {
+				Type:         schema.TypeString,
+				Description:  "The YAML or JSON manifest to apply, multiple documents are supported.",
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "file"},
+			},
+			"file": {
+				Type:         schema.TypeString,
+				Description:  "Path to a YAML or JSON manifest to apply, multiple documents are supported.",
+				Optional:     true,
+				ExactlyOneOf: []string{"content", "file"},
+			},
+			"field_manager": {
+				Type:        schema.TypeString,
+				Description: "The field manager used for server-side apply. Defaults to 'terraform-provider-kind'.",
+				Optional:    true,
+				Default:     defaultFieldManager,
+			},
+			"force_conflicts": {
+				Type:        schema.TypeBool,
+				Description: "Take ownership of fields managed by other field managers. Defaults to false.",
+				Optional:    true,
+				Default:     false,
+			},
+			"content_hash": {
+				Type:        schema.TypeString,
+				Description: "A hash of the applied manifest. It is reset when applied objects go missing or were changed in the cluster so they are applied again.",
+				Computed:    true,
+			},
+			"objects": {
+				Type:        schema.TypeList,
+				Description: "The objects applied from the manifest.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"api_version": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"kind": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"namespace": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceKindManifestCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	clusterName := d.Get("cluster_name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+	tflog.Info(ctx, "Applying manifest")
+
+	d.SetId(clusterName + "|" + id.UniqueId())
+	if err := applyManifest(ctx, d, nil); err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to apply manifest to kind cluster %q", clusterName), err, manifestAttributePath(d))
+	}
+	return resourceKindManifestRead(ctx, d, meta)
+}
+
+func resourceKindManifestUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	clusterName := d.Get("cluster_name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+	tflog.Info(ctx, "Updating manifest")
+
+	old, _ := d.GetChange("objects")
+	if err := applyManifest(ctx, d, flattenManifestObjects(old.([]interface{}))); err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to apply manifest to kind cluster %q", clusterName), err, manifestAttributePath(d))
+	}
+	return resourceKindManifestRead(ctx, d, meta)
+}
+
+// applyManifest applies the manifest of the resource and prunes previously
+// applied objects no longer part of it. The applied objects are recorded even
+// if applying fails part way, so they can be cleaned up later.
+func applyManifest(ctx context.Context, d *schema.ResourceData, previous []manifestObject) error {
+	clusterName := d.Get("cluster_name").(string)
+
+	content, err := manifestContent(d.Get("content").(string), d.Get("file").(string))
+	if err != nil {
+		return err
+	}
+	objects, err := parseManifest(content)
+	if err != nil {
+		return err
+	}
+
+	client, err := manifestClientForCluster(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	applied := []manifestObject{}
+	fieldManager := d.Get("field_manager").(string)
+	force := d.Get("force_conflicts").(bool)
+	for _, u := range objects {
+		obj, err := client.apply(ctx, u, fieldManager, force)
+		if err != nil {
+			d.Set("objects", expandManifestObjects(mergeManifestObjects(applied, previous)))
+			return err
+		}
+		tflog.Debug(ctx, "Applied manifest object", map[string]interface{}{"object": obj.String()})
+		applied = append(applied, obj)
+	}
+
+	// prune objects that were removed from the manifest, newest first
+	current := make(map[manifestObject]bool, len(applied))
+	for _, obj := range applied {
+		current[obj] = true
+	}
+	for i := len(previous) - 1; i >= 0; i-- {
+		if current[previous[i]] {
+			continue
+		}
+		if err := client.delete(ctx, previous[i]); err != nil {
+			return err
+		}
+		tflog.Debug(ctx, "Pruned manifest object", map[string]interface{}{"object": previous[i].String()})
+	}
+
+	d.Set("objects", expandManifestObjects(applied))
+	d.Set("content_hash", manifestHash(content))
+	return nil
+}
+
+func resourceKindManifestRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	clusterName := d.Get("cluster_name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+
+	provider := newKindProvider(ctx)
+	exists, err := clusterExists(provider, clusterName)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to read kind cluster %q", clusterName), err, nil)
+	}
+	if !exists {
+		tflog.Info(ctx, "Cluster not found, removing kind_manifest from state")
+		d.SetId("")
+		return nil
+	}
+	// a stopped cluster can't be inspected, keep the last known state
+	if running, err := clusterRunning(provider, clusterName); err == nil && !running {
+		tflog.Info(ctx, "Cluster is stopped, keeping the last known state of kind_manifest")
+		return nil
+	}
+
+	client, err := manifestClientForCluster(ctx, clusterName)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to connect to kind cluster %q", clusterName), err, nil)
+	}
+
+	present := []manifestObject{}
+	for _, obj := range flattenManifestObjects(d.Get("objects").([]interface{})) {
+		exists, err := client.exists(ctx, obj)
+		if err != nil {
+			return errorDiagnostics(fmt.Sprintf("Unable to read manifest object %s", obj), err, nil)
+		}
+		if !exists {
+			tflog.Info(ctx, "Manifest object is missing, it will be applied again", map[string]interface{}{"object": obj.String()})
+			continue
+		}
+		present = append(present, obj)
+	}
+
+	drifted := len(present) != len(d.Get("objects").([]interface{}))
+	if !drifted {
+		drifted, err = manifestDrifted(ctx, client, d)
+		if err != nil {
+			return errorDiagnostics("Unable to compare manifest objects with the cluster", err, nil)
+		}
+	}
+	if drifted {
+		// forces the next plan to show an update re-applying the manifest
+		d.Set("content_hash", "")
+	}
+	d.Set("objects", expandManifestObjects(present))
+	return nil
+}
+
+// manifestDrifted reports whether fields the manifest sets were changed in
+// the cluster. A manifest that can't be read anymore is left to the next plan,
+// which re-applies it once its content is known.
+func manifestDrifted(ctx context.Context, client *manifestClient, d *schema.ResourceData) (bool, error) {
+	content, err := manifestContent(d.Get("content").(string), d.Get("file").(string))
+	if err != nil || manifestHash(content) != d.Get("content_hash").(string) {
+		return false, nil
+	}
+	objects, err := parseManifest(content)
+	if err != nil {
+		return false, nil
+	}
+	fieldManager := d.Get("field_manager").(string)
+	force := d.Get("force_conflicts").(bool)
+	for _, u := range objects {
+		drifted, err := client.drifted(ctx, u, fieldManager, force)
+		if err != nil {
+			return false, err
+		}
+		if drifted {
+			tflog.Info(ctx, "Manifest object changed in the cluster, it will be applied again", map[string]interface{}{"object": manifestObjectFor(u).String()})
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func resourceKindManifestDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	clusterName := d.Get("cluster_name").(string)
+	ctx = tflog.SetField(ctx, "cluster_name", clusterName)
+	tflog.Info(ctx, "Deleting manifest objects")
+
+	client, err := manifestClientForCluster(ctx, clusterName)
+	if err != nil {
+		// nothing left to prune if the cluster is gone
+		tflog.Info(ctx, "Cluster not reachable, skipping manifest cleanup", map[string]interface{}{"error": err.Error()})
+		d.SetId("")
+		return nil
+	}
+
+	objects := flattenManifestObjects(d.Get("objects").([]interface{}))
+	for i := len(objects) - 1; i >= 0; i-- {
+		if err := client.delete(ctx, objects[i]); err != nil {
+			return errorDiagnostics(fmt.Sprintf("Unable to delete manifest object %s", objects[i]), err, nil)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceKindManifestCustomizeDiff plans a re-apply whenever the manifest
+// content, including the content of a referenced file, changed or applied
+// objects went missing.
+func resourceKindManifestCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("content") || !d.NewValueKnown("file") {
+		if err := d.SetNewComputed("content_hash"); err != nil {
+			return err
+		}
+		return d.SetNewComputed("objects")
+	}
+	content, err := manifestContent(d.Get("content").(string), d.Get("file").(string))
+	if err != nil {
+		// the file may be created by another resource during apply
+		if err := d.SetNewComputed("content_hash"); err != nil {
+			return err
+		}
+		return d.SetNewComputed("objects")
+	}
+	if _, err := parseManifest(content); err != nil {
+		return err
+	}
+	if hash := manifestHash(content); hash != d.Get("content_hash").(string) {
+		if err := d.SetNew("content_hash", hash); err != nil {
+			return err
+		}
+		return d.SetNewComputed("objects")
+	}
+	return nil
+}
+
+// mergeManifestObjects returns the objects of a followed by those of b not
+// already in a.
+func mergeManifestObjects(a, b []manifestObject) []manifestObject {
+	seen := make(map[manifestObject]bool, len(a))
+	merged := append([]manifestObject{}, a...)
+	for _, obj := range a {
+		seen[obj] = true
+	}
+	for _, obj := range b {
+		if !seen[obj] {
+			merged = append(merged, obj)
+		}
+	}
+	return merged
+}
+
+// manifestAttributePath returns the path of the attribute the manifest of a
+// kind_manifest is read from.
+func manifestAttributePath(d *schema.ResourceData) cty.Path {
+	if d.Get("file").(string) != "" {
+		return cty.GetAttrPath("file")
+	}
+	return cty.GetAttrPath("content")
+}
+
+func manifestClientForCluster(ctx context.Context, clusterName string) (*manifestClient, error) {
+	provider := newKindProvider(ctx)
+	config, err := restConfigForCluster(provider, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return newManifestClient(config)
+}
+
+func manifestContent(content, file string) (string, error) {
+	if file == "" {
+		return content, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read manifest file %q: %s", file, err)
+	}
+	return string(data), nil
+}
+
+func manifestHash(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+func flattenManifestObjects(l []interface{}) []manifestObject {
+	objects := make([]manifestObject, 0, len(l))
+	for _, o := range l {
+		data := o.(map[string]interface{})
+		objects = append(objects, manifestObject{
+			APIVersion: data["api_version"].(string),
+			Kind:       data["kind"].(string),
+			Namespace:  data["namespace"].(string),
+			Name:       data["name"].(string),
+		})
+	}
+	return objects
+}
+
+func expandManifestObjects(objects []manifestObject) []interface{} {
+	l := make([]interface{}, 0, len(objects))
+	for _, obj := range objects {
+		l = append(l, map[string]interface{}{
+			"api_version": obj.APIVersion,
+			"kind":        obj.Kind,
+			"namespace":   obj.Namespace,
+			"name":        obj.Name,
+		})
+	}
+	return l
+}
@@ -0,0 +1,170 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceManifestSchema(t *testing.T) {
+	r := resourceManifest()
+	if r.UpdateContext == nil {
+		t.Error("Update function should not be nil")
+	}
+
+	fields := r.Schema
+	if !fields["cluster_name"].Required || !fields["cluster_name"].ForceNew {
+		t.Error("'cluster_name' should be Required and ForceNew")
+	}
+	if fields["field_manager"].Default != defaultFieldManager {
+		t.Errorf("'field_manager' should default to %q", defaultFieldManager)
+	}
+	if !fields["objects"].Computed {
+		t.Error("'objects' should be Computed")
+	}
+}
+
+func TestManifestObjectsRoundTrip(t *testing.T) {
+	objects := []manifestObject{
+		{APIVersion: "v1", Kind: "Namespace", Name: "demo"},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "demo", Name: "settings"},
+	}
+	got := flattenManifestObjects(expandManifestObjects(objects))
+	if len(got) != len(objects) {
+		t.Fatalf("expected %d objects, got %d", len(objects), len(got))
+	}
+	for i := range objects {
+		if got[i] != objects[i] {
+			t.Errorf("expected %v, got %v", objects[i], got[i])
+		}
+	}
+}
+
+func TestMergeManifestObjects(t *testing.T) {
+	a := manifestObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "a"}
+	b := manifestObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "b"}
+	c := manifestObject{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "c"}
+
+	got := mergeManifestObjects([]manifestObject{a, b}, []manifestObject{b, c})
+	if len(got) != 3 || got[0] != a || got[1] != b || got[2] != c {
+		t.Errorf("expected [a b c], got %v", got)
+	}
+}
+
+func TestResourceManifestCustomizeDiff_FileChanged(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "manifest.yaml")
+	content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+	os.WriteFile(file, []byte(content), 0644)
+
+	state := &terraform.InstanceState{
+		ID: "test|1",
+		Attributes: map[string]string{
+			"id":              "test|1",
+			"cluster_name":    "test",
+			"file":            file,
+			"field_manager":   defaultFieldManager,
+			"force_conflicts": "false",
+			"content_hash":    manifestHash(content),
+			"objects.#":       "0",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"cluster_name": "test",
+		"file":         file,
+	})
+
+	diff, err := resourceManifest().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("expected no diff for an unchanged file, got %v", diff)
+	}
+
+	os.WriteFile(file, []byte(content+"data:\n  key: value\n"), 0644)
+
+	diff, err = resourceManifest().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || diff.Empty() {
+		t.Fatal("expected a diff when the file changed")
+	}
+	if diff.RequiresNew() {
+		t.Error("expected the manifest to be updated in place")
+	}
+}
+
+func TestAccManifest(t *testing.T) {
+	resourceName := "kind_manifest.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-manifest-test")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccManifestConfig(clusterName, true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "objects.#", "3"),
+					resource.TestCheckResourceAttr(resourceName, "objects.0.kind", "Namespace"),
+					resource.TestCheckResourceAttr(resourceName, "objects.1.namespace", "demo"),
+					resource.TestCheckResourceAttr(resourceName, "objects.2.namespace", "default"),
+					resource.TestCheckResourceAttrSet(resourceName, "content_hash"),
+				),
+			},
+			{
+				// dropping the second ConfigMap prunes it from the cluster
+				Config: testAccManifestConfig(clusterName, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "objects.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccManifestConfig(clusterName string, withExtra bool) string {
+	extra := ""
+	if withExtra {
+		extra = `
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: extra
+`
+	}
+	return fmt.Sprintf(`
+resource "kind_cluster" "test" {
+  name           = "%s"
+  wait_for_ready = true
+}
+
+resource "kind_manifest" "test" {
+  cluster_name = kind_cluster.test.name
+  content      = <<-YAML
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: demo
+data:
+  key: value
+%s
+YAML
+}
+`, clusterName, extra)
+}
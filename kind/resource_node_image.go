@@ -0,0 +1,295 @@
+package kind
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"sigs.k8s.io/kind/pkg/build/nodeimage"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+func resourceNodeImage() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceKindNodeImageCreate,
+		ReadContext:   resourceKindNodeImageRead,
+		DeleteContext: resourceKindNodeImageDelete,
+
+		CustomizeDiff: resourceKindNodeImageCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"source": {
+				Type:        schema.TypeString,
+				Description: "What to build the node image from: a Kubernetes source directory, a release tarball (path or URL) or a release version such as 'v1.31.0'.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"image": {
+				Type:        schema.TypeString,
+				Description: "The name:tag of the resulting node image. Defaults to 'kindest/node:latest'.",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     nodeimage.DefaultImage,
+			},
+			"base_image": {
+				Type:        schema.TypeString,
+				Description: "The base image to build the node image on. Defaults to the base image of the embedded kind version.",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     nodeimage.DefaultBaseImage,
+			},
+			"source_paths": {
+				Type:        schema.TypeList,
+				Description: "Paths relative to a source directory that isn't a git checkout to hash instead of the whole tree, e.g. 'cmd' and 'pkg'.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"arch": {
+				Type:        schema.TypeString,
+				Description: "The architecture to build for, e.g. 'amd64' or 'arm64'. Defaults to the host architecture.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"source_hash": {
+				Type:        schema.TypeString,
+				Description: "A hash of the source, the image is rebuilt when it changes.",
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"image_id": {
+				Type:        schema.TypeString,
+				Description: "The ID of the built image in the local container runtime.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceKindNodeImageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	source := d.Get("source").(string)
+	image := d.Get("image").(string)
+
+	ctx = tflog.SetField(ctx, "node_image", image)
+	tflog.Info(ctx, "Building node image", map[string]interface{}{"source": source})
+
+	sourceHash, err := nodeImageSourceHash(source, expandStringList(d.Get("source_paths").([]interface{})))
+	if err != nil {
+		return errorDiagnostics("Unable to read node image source", err, cty.GetAttrPath("source"))
+	}
+
+	err = nodeimage.Build(
+		nodeimage.WithImage(image),
+		nodeimage.WithBaseImage(d.Get("base_image").(string)),
+		nodeimage.WithKubeParam(source),
+		nodeimage.WithArch(d.Get("arch").(string)),
+		nodeimage.WithLogger(newKindLogger(ctx)),
+	)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to build node image %q", image), err, nil)
+	}
+
+	d.SetId(image)
+	d.Set("source_hash", sourceHash)
+	return resourceKindNodeImageRead(ctx, d, meta)
+}
+
+func resourceKindNodeImageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	image := d.Get("image").(string)
+
+	imageID, err := localImageID(image)
+	if err != nil {
+		tflog.Info(ctx, "Node image not found locally, removing kind_node_image from state", map[string]interface{}{"node_image": image})
+		d.SetId("")
+		return nil
+	}
+	d.Set("image_id", imageID)
+	return nil
+}
+
+func resourceKindNodeImageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	image := d.Get("image").(string)
+
+	// The image may still be used by clusters, failing to remove it is not an error.
+	var diags diag.Diagnostics
+	lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "image", "rm", image))
+	if err != nil {
+		diags = append(diags, warningDiagnostic(fmt.Sprintf("Unable to remove node image %s", image), fmt.Errorf("%s: %v", err, lines)))
+	}
+
+	d.SetId("")
+	return diags
+}
+
+// resourceKindNodeImageCustomizeDiff recomputes the source hash on every plan
+// so changes to the Kubernetes sources trigger a rebuild.
+func resourceKindNodeImageCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+	sourceHash, err := nodeImageSourceHash(d.Get("source").(string), expandStringList(d.Get("source_paths").([]interface{})))
+	if err != nil {
+		return err
+	}
+	if sourceHash != d.Get("source_hash").(string) {
+		tflog.Info(ctx, "Source of node image changed, it will be rebuilt", map[string]interface{}{"node_image": d.Get("image").(string)})
+		return d.SetNew("source_hash", sourceHash)
+	}
+	return nil
+}
+
+// sourceHashExcludes are directories skipped when hashing a source directory
+// that isn't a git checkout, build outputs would otherwise change the hash on
+// every build.
+var sourceHashExcludes = map[string]bool{
+	".git":       true,
+	".make":      true,
+	"_artifacts": true,
+	"_output":    true,
+	"_tmp":       true,
+}
+
+// nodeImageSourceHash returns a hash identifying the state of a node image
+// source. Files are hashed by content, git checkouts by HEAD and pending
+// changes. Other directories are hashed by the paths and contents of their
+// files, limited to paths if given and skipping sourceHashExcludes. Anything
+// else, e.g. a release version or URL, is hashed as is.
+func nodeImageSourceHash(source string, paths []string) (string, error) {
+	h := sha256.New()
+
+	info, err := os.Stat(source)
+	switch {
+	case err != nil:
+		io.WriteString(h, source)
+	case info.Mode().IsRegular():
+		if err := hashFile(h, source); err != nil {
+			return "", fmt.Errorf("failed to hash %q: %s", source, err)
+		}
+	case info.IsDir():
+		isGit, err := hashGitSource(h, source)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %q: %s", source, err)
+		}
+		if isGit {
+			break
+		}
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+		for _, p := range paths {
+			if err := hashSourceTree(h, source, p); err != nil {
+				return "", fmt.Errorf("failed to hash %q: %s", source, err)
+			}
+		}
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// hashGitSource hashes a git checkout by HEAD, the diff of the tracked files
+// against it and the paths and contents of the untracked files. It returns
+// false without hashing anything if source isn't the top level of a checkout,
+// e.g. a directory below an unrelated repository.
+func hashGitSource(h io.Writer, source string) (bool, error) {
+	lines, err := exec.OutputLines(exec.Command("git", "-C", source, "rev-parse", "--show-toplevel", "HEAD"))
+	if err != nil || len(lines) != 2 {
+		return false, nil
+	}
+	dir, err := filepath.Abs(source)
+	if err == nil {
+		dir, err = filepath.EvalSymlinks(dir)
+	}
+	if err != nil {
+		return false, err
+	}
+	if toplevel, err := filepath.EvalSymlinks(lines[0]); err != nil || toplevel != dir {
+		return false, nil
+	}
+	fmt.Fprintln(h, lines[1])
+
+	diff, err := exec.Output(exec.Command("git", "-C", source, "diff", "--binary", "--no-color", "--no-ext-diff", "--no-textconv", "HEAD"))
+	if err != nil {
+		return false, fmt.Errorf("failed to diff against HEAD: %s", err)
+	}
+	h.Write(diff)
+
+	untracked, err := exec.Output(exec.Command("git", "-C", source, "ls-files", "-z", "--others", "--exclude-standard"))
+	if err != nil {
+		return false, fmt.Errorf("failed to list untracked files: %s", err)
+	}
+	for _, name := range strings.Split(string(untracked), "\x00") {
+		if name == "" {
+			continue
+		}
+		file := filepath.Join(source, filepath.FromSlash(name))
+		info, err := os.Lstat(file)
+		if err != nil {
+			return false, err
+		}
+		if !info.Mode().IsRegular() {
+			target, _ := os.Readlink(file)
+			fmt.Fprintf(h, "%s -> %s\n", name, target)
+			continue
+		}
+		fmt.Fprintf(h, "%s %d\n", name, info.Size())
+		if err := hashFile(h, file); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// hashSourceTree hashes the relative paths and contents of the files below
+// path in the source directory.
+func hashSourceTree(h io.Writer, source, path string) error {
+	if !filepath.IsLocal(path) {
+		return fmt.Errorf("source path %q must be relative to the source directory", path)
+	}
+	return filepath.WalkDir(filepath.Join(source, path), func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, file)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir() && sourceHashExcludes[entry.Name()]:
+			return filepath.SkipDir
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s -> %s\n", filepath.ToSlash(rel), target)
+		case entry.Type().IsRegular():
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s %d\n", filepath.ToSlash(rel), info.Size())
+			return hashFile(h, file)
+		}
+		return nil
+	})
+}
+
+// hashFile writes the content of a file to h.
+func hashFile(h io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
@@ -0,0 +1,285 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"sigs.k8s.io/kind/pkg/build/nodeimage"
+)
+
+func TestResourceNodeImageSchema(t *testing.T) {
+	fields := resourceNodeImage().Schema
+
+	if !fields["source"].Required || !fields["source"].ForceNew {
+		t.Error("'source' should be Required and ForceNew")
+	}
+	if fields["image"].Default != nodeimage.DefaultImage {
+		t.Errorf("'image' should default to %q", nodeimage.DefaultImage)
+	}
+	if !fields["source_hash"].Computed || !fields["source_hash"].ForceNew {
+		t.Error("'source_hash' should be Computed and ForceNew")
+	}
+	if !fields["image_id"].Computed {
+		t.Error("'image_id' should be Computed")
+	}
+}
+
+func TestNodeImageSourceHash(t *testing.T) {
+	t.Run("VersionIsStable", func(t *testing.T) {
+		a, _ := nodeImageSourceHash("v1.31.0", nil)
+		b, _ := nodeImageSourceHash("v1.31.0", nil)
+		c, _ := nodeImageSourceHash("v1.31.1", nil)
+		if a != b {
+			t.Error("expected hash of the same version to be stable")
+		}
+		if a == c {
+			t.Error("expected hash of different versions to differ")
+		}
+	})
+
+	t.Run("FileContentChanges", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "kubernetes-server-linux-amd64.tar.gz")
+		os.WriteFile(file, []byte("one"), 0644)
+		a, err := nodeImageSourceHash(file, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(file, []byte("two"), 0644)
+		b, err := nodeImageSourceHash(file, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a == b {
+			t.Error("expected hash to change with file content")
+		}
+	})
+
+	t.Run("DirectoryChanges", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module k8s.io/kubernetes"), 0644)
+		a, err := nodeImageSourceHash(dir, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644)
+		b, err := nodeImageSourceHash(dir, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a == b {
+			t.Error("expected hash to change when files are added")
+		}
+	})
+}
+
+func TestNodeImageSourceHash_Directory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func(paths []string) string {
+		t.Helper()
+		h, err := nodeImageSourceHash(dir, paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	write("go.mod", "module k8s.io/kubernetes")
+	write("pkg/kubelet/kubelet.go", "package kubelet")
+	write("docs/README.md", "docs")
+	all, pkg := hash(nil), hash([]string{"pkg"})
+
+	// build outputs don't change the hash
+	write("_output/bin/kubelet", "binary")
+	write("_output/local/go/cache", "cache")
+	if got := hash(nil); got != all {
+		t.Error("expected _output to be excluded")
+	}
+
+	// touching a file without changing it doesn't either
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "go.mod"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := hash(nil); got != all {
+		t.Error("expected the modification time to be ignored")
+	}
+
+	// changes outside of the chosen paths are ignored
+	write("docs/README.md", "more docs")
+	if got := hash([]string{"pkg"}); got != pkg {
+		t.Error("expected changes outside of source_paths to be ignored")
+	}
+	if got := hash(nil); got == all {
+		t.Error("expected content changes to change the hash")
+	}
+	write("pkg/kubelet/kubelet.go", "package kubelet // changed")
+	if got := hash([]string{"pkg"}); got == pkg {
+		t.Error("expected content changes in source_paths to change the hash")
+	}
+
+	for _, p := range []string{"../other", "/etc", "missing"} {
+		if _, err := nodeImageSourceHash(dir, []string{p}); err == nil {
+			t.Errorf("expected an error for source path %q", p)
+		}
+	}
+}
+
+func TestNodeImageSourceHash_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func(source string) string {
+		t.Helper()
+		h, err := nodeImageSourceHash(source, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	git("init", "-q")
+	write("go.mod", "module k8s.io/kubernetes")
+	write("pkg/kubelet/kubelet.go", "package kubelet")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	clean := hash(dir)
+
+	write("pkg/kubelet/kubelet.go", "package kubelet // one")
+	dirty := hash(dir)
+	if dirty == clean {
+		t.Error("expected an uncommitted change to change the hash")
+	}
+	// the file is already dirty, only its content changes
+	write("pkg/kubelet/kubelet.go", "package kubelet // two")
+	if got := hash(dir); got == dirty {
+		t.Error("expected another change of a dirty file to change the hash")
+	}
+
+	write("pkg/new.go", "package pkg")
+	untracked := hash(dir)
+	write("pkg/new.go", "package pkg // changed")
+	if got := hash(dir); got == untracked {
+		t.Error("expected a change of an untracked file to change the hash")
+	}
+
+	// a directory below a checkout is hashed by its files like any other
+	// directory, not by the state of the parent repository
+	sub := hash(filepath.Join(dir, "pkg"))
+	write("go.mod", "module k8s.io/kubernetes // changed")
+	if got := hash(filepath.Join(dir, "pkg")); got != sub {
+		t.Error("expected changes of the parent repository outside the directory to be ignored")
+	}
+}
+
+func TestResourceNodeImageCustomizeDiff_SourceChanged(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "kubernetes.tar.gz")
+	os.WriteFile(file, []byte("old"), 0644)
+	oldHash, _ := nodeImageSourceHash(file, nil)
+
+	state := &terraform.InstanceState{
+		ID: "kindest/node:test",
+		Attributes: map[string]string{
+			"id":          "kindest/node:test",
+			"source":      file,
+			"image":       "kindest/node:test",
+			"base_image":  nodeimage.DefaultBaseImage,
+			"source_hash": oldHash,
+			"image_id":    "sha256:abc",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"source": file,
+		"image":  "kindest/node:test",
+	})
+
+	diff, err := resourceNodeImage().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && diff.RequiresNew() {
+		t.Error("expected no replacement when the source is unchanged")
+	}
+
+	os.WriteFile(file, []byte("new"), 0644)
+
+	diff, err = resourceNodeImage().Diff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || !diff.RequiresNew() {
+		t.Error("expected replacement when the source changed")
+	}
+}
+
+func TestAccNodeImage(t *testing.T) {
+	resourceName := "kind_node_image.test"
+	image := fmt.Sprintf("kindest/node:%s", acctest.RandomWithPrefix("tf-acc"))
+	clusterName := acctest.RandomWithPrefix("tf-acc-node-image-test")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckKindClusterResourceDestroy(clusterName),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccNodeImageConfig(image, clusterName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "image", image),
+					resource.TestCheckResourceAttrSet(resourceName, "image_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "source_hash"),
+					resource.TestCheckResourceAttr("kind_cluster.test", "node_image", image),
+				),
+			},
+		},
+	})
+}
+
+func testAccNodeImageConfig(image, clusterName string) string {
+	return fmt.Sprintf(`
+resource "kind_node_image" "test" {
+  source = "v1.31.0"
+  image  = "%s"
+}
+
+resource "kind_cluster" "test" {
+  name       = "%s"
+  node_image = kind_node_image.test.image
+}
+`, image, clusterName)
+}
@@ -0,0 +1,339 @@
+package kind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"sigs.k8s.io/kind/pkg/cluster"
+	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+// anonymousVolumeRegexp matches the generated names of anonymous volumes.
+var anonymousVolumeRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+const (
+	defaultRegistryImage    = "registry:2"
+	defaultRegistryHostPort = 5001
+	registryContainerPort   = 5000
+)
+
+func resourceRegistry() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceKindRegistryCreate,
+		ReadContext:   resourceKindRegistryRead,
+		DeleteContext: resourceKindRegistryDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Description: "The name of the registry container. Nodes of clusters using this registry reach it under this name.",
+				Required:    true,
+				ForceNew:    true,
+			},
+			"image": {
+				Type:        schema.TypeString,
+				Description: "The registry image to run. Defaults to 'registry:2'.",
+				Optional:    true,
+				ForceNew:    true,
+				Default:     defaultRegistryImage,
+			},
+			"host_port": {
+				Type:         schema.TypeInt,
+				Description:  "The port on the host the registry is published on. Defaults to 5001.",
+				Optional:     true,
+				ForceNew:     true,
+				Default:      defaultRegistryHostPort,
+				ValidateFunc: validation.IsPortNumber,
+			},
+			"listen_address": {
+				Type:         schema.TypeString,
+				Description:  "The host address the registry port is bound to. Defaults to '127.0.0.1'.",
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "127.0.0.1",
+				ValidateFunc: validation.IsIPAddress,
+			},
+			"volume": {
+				Type:        schema.TypeString,
+				Description: "A volume name or absolute host path mounted as the registry storage, so images survive recreating the registry container.",
+				Optional:    true,
+				ForceNew:    true,
+			},
+			"endpoint": {
+				Type:        schema.TypeString,
+				Description: "The address images are pushed to from the host, e.g. 'localhost:5001'.",
+				Computed:    true,
+			},
+			"container_id": {
+				Type:        schema.TypeString,
+				Description: "The ID of the registry container.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceKindRegistryCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	name := d.Get("name").(string)
+	image := d.Get("image").(string)
+	hostPort := d.Get("host_port").(int)
+	listenAddress := d.Get("listen_address").(string)
+	volume := d.Get("volume").(string)
+
+	ctx = tflog.SetField(ctx, "registry", name)
+	tflog.Info(ctx, "Creating local registry")
+
+	args := []string{
+		"run", "-d", "--restart=always",
+		"--name", name,
+		"-p", fmt.Sprintf("%s:%d:%d", listenAddress, hostPort, registryContainerPort),
+	}
+	if volume != "" {
+		args = append(args, "-v", volume+":/var/lib/registry")
+	}
+	args = append(args, image)
+
+	lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), args...))
+	if err != nil {
+		err = fmt.Errorf("%s: %v", err, lines)
+		var path cty.Path
+		if _, ok := hostPortInUse(err); ok {
+			path = cty.GetAttrPath("host_port")
+		}
+		return errorDiagnostics(fmt.Sprintf("Unable to create registry container %q", name), err, path)
+	}
+
+	d.SetId(name)
+	return resourceKindRegistryRead(ctx, d, meta)
+}
+
+func resourceKindRegistryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	name := d.Id()
+
+	data, err := exec.Output(exec.Command(containerRuntime(), "container", "inspect", "-f", "{{ json . }}", name))
+	if err != nil {
+		tflog.Info(ctx, "Registry container not found, removing kind_registry from state", map[string]interface{}{"registry": name})
+		d.SetId("")
+		return nil
+	}
+	registry, err := parseRegistryContainer(data)
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to inspect registry container %q", name), err, nil)
+	}
+
+	d.Set("name", registry.Name)
+	d.Set("image", registry.Image)
+	d.Set("container_id", registry.ID)
+	if registry.HostPort != 0 {
+		d.Set("host_port", registry.HostPort)
+	}
+	if registry.ListenAddress != "" {
+		d.Set("listen_address", registry.ListenAddress)
+	}
+	d.Set("volume", registry.Volume)
+	d.Set("endpoint", fmt.Sprintf("localhost:%d", d.Get("host_port").(int)))
+	return nil
+}
+
+// registryContainer is the configuration of a registry container as far as
+// it is reflected in the kind_registry attributes.
+type registryContainer struct {
+	ID            string
+	Name          string
+	Image         string
+	HostPort      int
+	ListenAddress string
+	Volume        string
+}
+
+// parseRegistryContainer reads a registry container from the output of
+// `container inspect -f '{{ json . }}'`, so imported registries get their
+// attributes from the container.
+func parseRegistryContainer(data []byte) (registryContainer, error) {
+	var inspect struct {
+		ID     string `json:"Id"`
+		Name   string
+		Config struct {
+			Image string
+		}
+		HostConfig struct {
+			PortBindings map[string][]struct {
+				HostIP   string `json:"HostIp"`
+				HostPort string
+			}
+		}
+		Mounts []struct {
+			Type        string
+			Name        string
+			Source      string
+			Destination string
+		}
+	}
+	if err := json.Unmarshal(data, &inspect); err != nil {
+		return registryContainer{}, fmt.Errorf("failed to parse container: %s", err)
+	}
+
+	registry := registryContainer{
+		ID:    inspect.ID,
+		Name:  strings.TrimPrefix(inspect.Name, "/"),
+		Image: inspect.Config.Image,
+	}
+	if bindings := inspect.HostConfig.PortBindings[fmt.Sprintf("%d/tcp", registryContainerPort)]; len(bindings) > 0 {
+		port, err := strconv.Atoi(bindings[0].HostPort)
+		if err != nil {
+			return registry, fmt.Errorf("invalid host port %q: %s", bindings[0].HostPort, err)
+		}
+		registry.HostPort = port
+		registry.ListenAddress = bindings[0].HostIP
+	}
+	for _, m := range inspect.Mounts {
+		if m.Destination != "/var/lib/registry" {
+			continue
+		}
+		switch {
+		case m.Type == "bind":
+			registry.Volume = m.Source
+		// the anonymous volume the registry image declares wasn't configured
+		case m.Type == "volume" && !anonymousVolumeRegexp.MatchString(m.Name):
+			registry.Volume = m.Name
+		}
+	}
+	return registry, nil
+}
+
+func resourceKindRegistryDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	name := d.Id()
+	ctx = tflog.SetField(ctx, "registry", name)
+	tflog.Info(ctx, "Deleting local registry")
+
+	lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "rm", "-f", name))
+	if err != nil {
+		return errorDiagnostics(fmt.Sprintf("Unable to delete registry container %q", name), fmt.Errorf("%s: %v", err, lines), nil)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// kindNetwork returns the name of the network kind attaches nodes to.
+func kindNetwork() string {
+	if network := os.Getenv("KIND_EXPERIMENTAL_DOCKER_NETWORK"); network != "" {
+		return network
+	}
+	return "kind"
+}
+
+// registryHostPort returns the host port a registry container publishes its
+// API on.
+func registryHostPort(name string) (int, error) {
+	lines, err := exec.OutputLines(exec.Command(containerRuntime(), "port", name, strconv.Itoa(registryContainerPort)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get published port of registry %q: %s", name, err)
+	}
+	if len(lines) == 0 {
+		return 0, fmt.Errorf("registry %q does not publish port %d", name, registryContainerPort)
+	}
+	// e.g. "127.0.0.1:5001" or "[::]:5001"
+	port := lines[0][strings.LastIndex(lines[0], ":")+1:]
+	return strconv.Atoi(port)
+}
+
+// connectRegistryToKindNetwork attaches a registry container to the kind
+// network unless it is already attached.
+func connectRegistryToKindNetwork(name string) error {
+	network := kindNetwork()
+	lines, err := exec.OutputLines(exec.Command(containerRuntime(), "container", "inspect", "-f", "{{ json .NetworkSettings.Networks }}", name))
+	if err != nil {
+		return fmt.Errorf("failed to inspect registry %q: %s", name, err)
+	}
+	if len(lines) == 1 && strings.Contains(lines[0], fmt.Sprintf("%q", network)) {
+		return nil
+	}
+	out, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "network", "connect", network, name))
+	if err != nil {
+		return fmt.Errorf("failed to connect registry %q to network %q: %s: %v", name, network, err, out)
+	}
+	return nil
+}
+
+// registryHostsToml returns the containerd hosts.toml redirecting pulls for
+// the registry's host address to the registry container on the kind network.
+func registryHostsToml(name string) string {
+	return fmt.Sprintf("[host.\"http://%s:%d\"]\n", name, registryContainerPort)
+}
+
+// localRegistryHostingConfigMap returns the ConfigMap documenting the local
+// registry as described in KEP-1755.
+func localRegistryHostingConfigMap(host string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: local-registry-hosting
+  namespace: kube-public
+data:
+  localRegistryHosting.v1: |
+    host: "%s"
+    help: "https://kind.sigs.k8s.io/docs/user/local-registry/"
+`, host)
+}
+
+// configureLocalRegistries wires registry containers into a created cluster:
+// it connects them to the kind network, points containerd on every node at
+// them and publishes the local-registry-hosting ConfigMap for the first one.
+func configureLocalRegistries(provider *cluster.Provider, clusterName string, registries []string) error {
+	if len(registries) == 0 {
+		return nil
+	}
+
+	allNodes, err := provider.ListNodes(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes for cluster %q: %s", clusterName, err)
+	}
+	internalNodes, err := nodeutils.InternalNodes(allNodes)
+	if err != nil {
+		return err
+	}
+
+	hosts := []string{}
+	for _, registry := range registries {
+		if err := connectRegistryToKindNetwork(registry); err != nil {
+			return err
+		}
+		port, err := registryHostPort(registry)
+		if err != nil {
+			return err
+		}
+		host := fmt.Sprintf("localhost:%d", port)
+		for _, node := range internalNodes {
+			if err := writeRegistryHostsToml(node, host, registryHostsToml(registry)); err != nil {
+				return err
+			}
+		}
+		hosts = append(hosts, host)
+	}
+
+	controlPlane, err := nodeutils.BootstrapControlPlaneNode(allNodes)
+	if err != nil {
+		return err
+	}
+	cmd := controlPlane.Command("kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "apply", "-f", "-")
+	cmd.SetStdin(strings.NewReader(localRegistryHostingConfigMap(hosts[0])))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create local-registry-hosting ConfigMap: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package kind
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceRegistrySchema(t *testing.T) {
+	fields := resourceRegistry().Schema
+
+	if !fields["name"].Required || !fields["name"].ForceNew {
+		t.Error("'name' should be Required and ForceNew")
+	}
+	if fields["image"].Default != defaultRegistryImage {
+		t.Errorf("'image' should default to %q", defaultRegistryImage)
+	}
+	if fields["host_port"].Default != defaultRegistryHostPort {
+		t.Errorf("'host_port' should default to %d", defaultRegistryHostPort)
+	}
+	if !fields["endpoint"].Computed {
+		t.Error("'endpoint' should be Computed")
+	}
+	if resourceRegistry().Importer == nil {
+		t.Error("Importer should not be nil")
+	}
+}
+
+func TestParseRegistryContainer(t *testing.T) {
+	anonymous := strings.Repeat("a1", 32)
+	cases := []struct {
+		Name     string
+		Data     string
+		Expected registryContainer
+	}{
+		{
+			Name: "Defaults",
+			Data: `{"Id":"abc","Name":"/kind-registry","Config":{"Image":"registry:2"},
+				"HostConfig":{"PortBindings":{"5000/tcp":[{"HostIp":"127.0.0.1","HostPort":"5001"}]}},
+				"Mounts":[{"Type":"volume","Name":"` + anonymous + `","Source":"/var/lib/docker/volumes/` + anonymous + `/_data","Destination":"/var/lib/registry"}]}`,
+			Expected: registryContainer{ID: "abc", Name: "kind-registry", Image: "registry:2", HostPort: 5001, ListenAddress: "127.0.0.1"},
+		},
+		{
+			Name: "NamedVolume",
+			Data: `{"Id":"abc","Name":"/kind-registry","Config":{"Image":"registry:2.8"},
+				"HostConfig":{"PortBindings":{"5000/tcp":[{"HostIp":"0.0.0.0","HostPort":"5002"}]}},
+				"Mounts":[{"Type":"volume","Name":"registry-data","Destination":"/var/lib/registry"}]}`,
+			Expected: registryContainer{ID: "abc", Name: "kind-registry", Image: "registry:2.8", HostPort: 5002, ListenAddress: "0.0.0.0", Volume: "registry-data"},
+		},
+		{
+			Name: "BindMount",
+			Data: `{"Id":"abc","Name":"kind-registry","Config":{"Image":"registry:2"},
+				"HostConfig":{"PortBindings":{"5000/tcp":[{"HostIp":"","HostPort":"5001"}]}},
+				"Mounts":[{"Type":"bind","Source":"/srv/registry","Destination":"/var/lib/registry"}]}`,
+			Expected: registryContainer{ID: "abc", Name: "kind-registry", Image: "registry:2", HostPort: 5001, Volume: "/srv/registry"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := parseRegistryContainer([]byte(tc.Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.Expected {
+				t.Errorf("expected %+v, got %+v", tc.Expected, got)
+			}
+		})
+	}
+
+	if _, err := parseRegistryContainer([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
+
+func TestKindNetwork(t *testing.T) {
+	t.Setenv("KIND_EXPERIMENTAL_DOCKER_NETWORK", "")
+	if got := kindNetwork(); got != "kind" {
+		t.Errorf("expected default network kind, got %q", got)
+	}
+	t.Setenv("KIND_EXPERIMENTAL_DOCKER_NETWORK", "custom")
+	if got := kindNetwork(); got != "custom" {
+		t.Errorf("expected network custom, got %q", got)
+	}
+}
+
+func TestRegistryContainerdConfig(t *testing.T) {
+	if _, err := normalizeToml(containerdConfigPathPatch); err != nil {
+		t.Errorf("config_path patch is not valid toml: %s", err)
+	}
+
+	hosts := registryHostsToml("kind-registry")
+	if _, err := normalizeToml(hosts); err != nil {
+		t.Errorf("hosts.toml is not valid toml: %s", err)
+	}
+	if !strings.Contains(hosts, `"http://kind-registry:5000"`) {
+		t.Errorf("hosts.toml should point at the registry container, got:\n%s", hosts)
+	}
+}
+
+func TestLocalRegistryHostingConfigMap(t *testing.T) {
+	cm := localRegistryHostingConfigMap("localhost:5001")
+	for _, want := range []string{"name: local-registry-hosting", "namespace: kube-public", `host: "localhost:5001"`} {
+		if !strings.Contains(cm, want) {
+			t.Errorf("expected ConfigMap to contain %q, got:\n%s", want, cm)
+		}
+	}
+}
+
+func TestAccRegistry(t *testing.T) {
+	resourceName := "kind_registry.test"
+	clusterName := acctest.RandomWithPrefix("tf-acc-registry-test")
+	registryName := acctest.RandomWithPrefix("tf-acc-registry")
+	registryPort := acctest.RandIntRange(20000, 30000)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			testAccCheckKindClusterResourceDestroy(clusterName),
+			testAccCheckKindRegistryResourceDestroy(registryName),
+		),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRegistryConfig(registryName, registryPort, clusterName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", registryName),
+					resource.TestCheckResourceAttr(resourceName, "endpoint", fmt.Sprintf("localhost:%d", registryPort)),
+					resource.TestCheckResourceAttrSet(resourceName, "container_id"),
+					resource.TestCheckResourceAttr("kind_cluster.test", "registries.0", registryName),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateId:     registryName,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckKindRegistryResourceDestroy(registryName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if err := exec.Command("docker", "inspect", registryName).Run(); err == nil {
+			return fmt.Errorf("registry container %q still exists", registryName)
+		}
+		return nil
+	}
+}
+
+func testAccRegistryConfig(registryName string, registryPort int, clusterName string) string {
+	return fmt.Sprintf(`
+resource "kind_registry" "test" {
+  name      = "%s"
+  host_port = %d
+}
+
+resource "kind_cluster" "test" {
+  name           = "%s"
+  wait_for_ready = true
+  registries     = [kind_registry.test.name]
+}
+`, registryName, registryPort, clusterName)
+}
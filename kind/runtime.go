@@ -0,0 +1,214 @@
+package kind
+
+import (
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/kind/pkg/exec"
+)
+
+const (
+	pullPolicyNever        = "never"
+	pullPolicyIfNotPresent = "if_not_present"
+	pullPolicyAlways       = "always"
+)
+
+// containerRuntime returns the container runtime binary used for image
+// operations on the host. It follows the same rules kind uses to pick a node
+// provider: KIND_EXPERIMENTAL_PROVIDER wins, otherwise the first available of
+// docker, nerdctl and podman is used, falling back to docker.
+func containerRuntime() string {
+	switch p := os.Getenv("KIND_EXPERIMENTAL_PROVIDER"); p {
+	case "docker", "podman", "nerdctl", "finch", "nerdctl.lima":
+		return p
+	}
+	for _, binary := range []string{"docker", "nerdctl", "podman"} {
+		if _, err := osexec.LookPath(binary); err == nil {
+			return binary
+		}
+	}
+	return "docker"
+}
+
+// dockerConfigPath returns the path of the docker client config holding
+// registry credentials, honouring DOCKER_CONFIG.
+func dockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// localImageID returns the image ID for a given image name from the local
+// container runtime.
+func localImageID(imageName string) (string, error) {
+	lines, err := exec.OutputLines(
+		exec.Command(containerRuntime(), "image", "inspect", "-f", "{{ .Id }}", imageName),
+	)
+	if err != nil {
+		return "", err
+	}
+	if len(lines) != 1 {
+		return "", fmt.Errorf("expected 1 line of output, got %d", len(lines))
+	}
+	return lines[0], nil
+}
+
+// localImageDigest returns the registry digest, e.g. sha256:..., of an image
+// in the local container runtime. Images that were built locally and never
+// pushed or pulled have no digest.
+func localImageDigest(imageName string) (string, error) {
+	lines, err := exec.OutputLines(
+		exec.Command(containerRuntime(), "image", "inspect", "-f", `{{ join .RepoDigests "\n" }}`, imageName),
+	)
+	if err != nil {
+		return "", err
+	}
+	repository, _, _ := strings.Cut(imageName, "@")
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	digest := ""
+	for _, line := range lines {
+		name, d, ok := strings.Cut(strings.TrimSpace(line), "@")
+		if !ok {
+			continue
+		}
+		if digest == "" || name == repository || strings.HasSuffix(name, "/"+repository) {
+			digest = d
+		}
+	}
+	if digest == "" {
+		return "", fmt.Errorf("image %q has no registry digest", imageName)
+	}
+	return digest, nil
+}
+
+// pullImage pulls an image into the local container runtime. Docker and
+// nerdctl read credentials from the docker config on their own, podman has to
+// be pointed at it explicitly.
+func pullImage(imageName string) error {
+	runtime := containerRuntime()
+	args := []string{"pull"}
+	if runtime == "podman" {
+		if path := dockerConfigPath(); path != "" {
+			if _, err := os.Stat(path); err == nil {
+				args = append(args, "--authfile", path)
+			}
+		}
+	}
+	args = append(args, imageName)
+	lines, err := exec.CombinedOutputLines(exec.Command(runtime, args...))
+	if err != nil {
+		return fmt.Errorf("%s pull %s failed: %s: %v", runtime, imageName, err, lines)
+	}
+	return nil
+}
+
+// ensureImage makes sure an image is present in the local container runtime
+// according to pullPolicy and returns its image ID.
+func ensureImage(imageName, pullPolicy string) (string, error) {
+	switch pullPolicy {
+	case pullPolicyAlways:
+		if err := pullImage(imageName); err != nil {
+			return "", err
+		}
+	case pullPolicyIfNotPresent:
+		if id, err := localImageID(imageName); err == nil {
+			return id, nil
+		}
+		if err := pullImage(imageName); err != nil {
+			return "", err
+		}
+	}
+	id, err := localImageID(imageName)
+	if err != nil {
+		return "", fmt.Errorf("image %q not present locally: %s", imageName, err)
+	}
+	return id, nil
+}
+
+// loadImageArchive loads a `docker save` tarball into the local container
+// runtime.
+func loadImageArchive(archivePath string) error {
+	if _, err := os.Stat(archivePath); err != nil {
+		return fmt.Errorf("image archive %q not readable: %s", archivePath, err)
+	}
+	lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "load", "-i", archivePath))
+	if err != nil {
+		return fmt.Errorf("failed to load image archive %q: %s: %v", archivePath, err, lines)
+	}
+	return nil
+}
+
+// loadNodeImageArchive loads a node image archive after verifying it
+// provides every node image, by digest if pinned, and returns the references
+// the loaded node images resolve by locally, keyed by node image. kind falls
+// back to pulling images that don't resolve.
+func loadNodeImageArchive(archivePath string, nodeImages []string) (map[string]string, error) {
+	images, err := readImageArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range nodeImages {
+		if err := verifyArchiveImage(images, image); err != nil {
+			return nil, fmt.Errorf("image archive %q does not provide node image %q: %s", archivePath, image, err)
+		}
+	}
+	if err := loadImageArchive(archivePath); err != nil {
+		return nil, err
+	}
+	refs := map[string]string{}
+	for _, image := range nodeImages {
+		ref, err := resolveLoadedImage(images, image)
+		if err != nil {
+			return nil, fmt.Errorf("node image %q does not resolve after loading image archive %q: %s", image, archivePath, err)
+		}
+		refs[image] = ref
+	}
+	return refs, nil
+}
+
+// resolveLoadedImage returns a reference to image after loading it from an
+// archive. docker's classic image store doesn't record the digest of loaded
+// images, a pinned image is then looked up by its config digest or its tag,
+// as verified by verifyArchiveImage, and tagged with the name of the image,
+// plus a tag made of the digest if it has none.
+func resolveLoadedImage(images []archiveImage, image string) (string, error) {
+	_, err := localImageID(image)
+	name, digest, pinned := strings.Cut(image, "@")
+	if err == nil || !pinned {
+		return image, err
+	}
+
+	id := ""
+	for _, img := range images {
+		if img.Config == digest {
+			id = digest
+		}
+	}
+	if id == "" {
+		if !imageHasTag(name) {
+			return "", fmt.Errorf("no image with config digest %s loaded", digest)
+		}
+		if id, err = localImageID(name); err != nil {
+			return "", err
+		}
+	}
+
+	ref := name
+	if !imageHasTag(name) {
+		ref = name + ":" + strings.Replace(digest, ":", "-", 1)
+	}
+	if lines, err := exec.CombinedOutputLines(exec.Command(containerRuntime(), "tag", id, ref)); err != nil {
+		return "", fmt.Errorf("failed to tag %s as %s: %s: %v", id, ref, err, lines)
+	}
+	return ref, nil
+}
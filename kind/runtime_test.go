@@ -0,0 +1,60 @@
+package kind
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestContainerRuntime_EnvOverride(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Env      string
+		Expected string
+	}{
+		{Name: "Podman", Env: "podman", Expected: "podman"},
+		{Name: "Docker", Env: "docker", Expected: "docker"},
+		{Name: "Nerdctl", Env: "nerdctl", Expected: "nerdctl"},
+		{Name: "Finch", Env: "finch", Expected: "finch"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Setenv("KIND_EXPERIMENTAL_PROVIDER", tc.Env)
+			if got := containerRuntime(); got != tc.Expected {
+				t.Errorf("expected %q, got %q", tc.Expected, got)
+			}
+		})
+	}
+}
+
+func TestContainerRuntime_FallsBackToDocker(t *testing.T) {
+	t.Setenv("KIND_EXPERIMENTAL_PROVIDER", "unknown")
+	t.Setenv("PATH", t.TempDir())
+	if got := containerRuntime(); got != "docker" {
+		t.Errorf("expected docker, got %q", got)
+	}
+}
+
+func TestDockerConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	if got, want := dockerConfigPath(), filepath.Join(dir, "config.json"); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestEnsureImage_NeverDoesNotPull(t *testing.T) {
+	t.Setenv("KIND_EXPERIMENTAL_PROVIDER", "docker")
+	t.Setenv("PATH", t.TempDir())
+	_, err := ensureImage("alpine", pullPolicyNever)
+	if err == nil {
+		t.Fatal("expected error when image is not present and pull_policy is never")
+	}
+}
+
+func TestLoadNodeImageArchive_MissingArchive(t *testing.T) {
+	_, err := loadNodeImageArchive(filepath.Join(t.TempDir(), "missing.tar"), []string{"kindest/node:v1.29.7"})
+	if err == nil {
+		t.Fatal("expected error for missing archive")
+	}
+}
@@ -2,6 +2,7 @@ package kind
 
 import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 //Schemas
@@ -20,14 +21,6 @@ func kindConfigFields() map[string]*schema.Schema {
 			Optional: false,
 			ForceNew: true,
 		},
-		"node": {
-			Type:     schema.TypeList,
-			Optional: true,
-			ForceNew: true,
-			Elem: &schema.Resource{
-				Schema: kindConfigNodeFields(),
-			},
-		},
 		"networking": {
 			Type:     schema.TypeList,
 			Optional: true,
@@ -53,6 +46,13 @@ func kindConfigFields() map[string]*schema.Schema {
 				},
 			},
 		},
+		"registry_mirror": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Resource{
+				Schema: kindConfigRegistryMirrorFields(),
+			},
+		},
 		"runtime_config": {
 			Type:     schema.TypeMap,
 			Optional: true,
@@ -64,11 +64,22 @@ func kindConfigFields() map[string]*schema.Schema {
 			Elem:     &schema.Schema{Type: schema.TypeString},
 		},
 	}
-	return forceNewAll(s)
+	s = forceNewAll(s)
+
+	// adding and removing workers is done in place, the resource's
+	// CustomizeDiff forces a new cluster for any other change of the nodes
+	s["node"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: kindConfigNodeFields(),
+		},
+	}
+	return s
 }
 
 // forceNewAll will take a schema and mark every attribute as ForceNew recursively.
-// This is a hack because we don't support updates to any part of the kind_config
+// This is a hack because we don't support updates to most of the kind_config
 // but ForceNew at the top level still allows in-line updates of attributes.
 func forceNewAll(s map[string]*schema.Schema) map[string]*schema.Schema {
 	for _, ss := range s {
@@ -90,8 +101,9 @@ func kindConfigNodeFields() map[string]*schema.Schema {
 			Optional: true,
 		},
 		"image": {
-			Type:     schema.TypeString,
-			Optional: true,
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: stringIsDigestPinnedImage,
 		},
 		"extra_mounts": {
 			Type:     schema.TypeList,
@@ -117,6 +129,23 @@ func kindConfigNodeFields() map[string]*schema.Schema {
 			Optional: true,
 			Elem:     &schema.Schema{Type: schema.TypeString},
 		},
+		"ingress_ready": {
+			Type:        schema.TypeBool,
+			Description: `optional: label the node ingress-ready=true and map the ingress HTTP and HTTPS ports to the host`,
+			Optional:    true,
+		},
+		"ingress_http_host_port": {
+			Type:         schema.TypeInt,
+			Description:  `optional: host port mapped to container port 80 of an ingress_ready node, 80 if unset`,
+			Optional:     true,
+			ValidateFunc: validation.IsPortNumber,
+		},
+		"ingress_https_host_port": {
+			Type:         schema.TypeInt,
+			Description:  `optional: host port mapped to container port 443 of an ingress_ready node, 443 if unset`,
+			Optional:     true,
+			ValidateFunc: validation.IsPortNumber,
+		},
 	}
 	return s
 }
@@ -211,3 +240,54 @@ func kindConfigNodeExtraPortMappingsFields() map[string]*schema.Schema {
 	}
 	return s
 }
+
+func kindConfigRegistryMirrorFields() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		"registry": {
+			Type:        schema.TypeString,
+			Description: `The registry host to mirror, e.g. docker.io or registry.k8s.io.`,
+			Required:    true,
+		},
+		"endpoints": {
+			Type:        schema.TypeList,
+			Description: `Mirror URLs tried in order before falling back to the registry itself.`,
+			Required:    true,
+			MinItems:    1,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"skip_verify": {
+			Type:        schema.TypeBool,
+			Description: `optional: skip TLS verification of the mirror endpoints`,
+			Optional:    true,
+		},
+		"ca_file": {
+			Type:        schema.TypeString,
+			Description: `optional: path on the host to a CA certificate used to verify the mirror endpoints`,
+			Optional:    true,
+		},
+		"auth": {
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: kindConfigRegistryMirrorAuthFields(),
+			},
+		},
+	}
+	return s
+}
+
+func kindConfigRegistryMirrorAuthFields() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		"username": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"password": {
+			Type:      schema.TypeString,
+			Required:  true,
+			Sensitive: true,
+		},
+	}
+	return s
+}
@@ -14,3 +14,13 @@ func normalizeToml(tomlString interface{}) (string, error) {
 	}
 	return tree.ToTomlString()
 }
+
+func expandStringList(l []interface{}) []string {
+	s := make([]string, 0, len(l))
+	for _, v := range l {
+		if str, ok := v.(string); ok {
+			s = append(s, str)
+		}
+	}
+	return s
+}
@@ -6,6 +6,8 @@ import (
 	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 )
 
+const ingressReadyLabel = "ingress-ready"
+
 // Flatteners
 
 func flattenKindConfig(d map[string]interface{}) *v1alpha4.Cluster {
@@ -40,6 +42,10 @@ func flattenKindConfig(d map[string]interface{}) *v1alpha4.Cluster {
 		}
 	}
 
+	if len(flattenKindConfigRegistryMirrors(d)) > 0 {
+		appendContainerdConfigPathPatch(obj)
+	}
+
 	runtimeConfig := mapKeyIfExists(d, "runtime_config")
 	if runtimeConfig != nil {
 		data := runtimeConfig.(map[string]interface{})
@@ -119,9 +125,37 @@ func flattenKindConfigNodes(d map[string]interface{}) v1alpha4.Node {
 		}
 	}
 
+	ingressReady := mapKeyIfExists(d, "ingress_ready")
+	if ingressReady != nil && ingressReady.(bool) {
+		// kind passes node labels to the kubelet as node-labels, which is what
+		// the usual ingress-ready kubeadm patch does as well
+		if obj.Labels == nil {
+			obj.Labels = make(map[string]string)
+		}
+		obj.Labels[ingressReadyLabel] = "true"
+		httpPort, httpsPort := ingressHostPorts(d)
+		obj.ExtraPortMappings = append(obj.ExtraPortMappings,
+			v1alpha4.PortMapping{ContainerPort: 80, HostPort: httpPort, Protocol: v1alpha4.PortMappingProtocolTCP},
+			v1alpha4.PortMapping{ContainerPort: 443, HostPort: httpsPort, Protocol: v1alpha4.PortMappingProtocolTCP},
+		)
+	}
+
 	return obj
 }
 
+// ingressHostPorts returns the host ports the ingress ports of an
+// ingress_ready node are mapped to, 80 and 443 unless they are set.
+func ingressHostPorts(d map[string]interface{}) (int32, int32) {
+	httpPort, httpsPort := int32(80), int32(443)
+	if p := mapKeyIfExists(d, "ingress_http_host_port"); p != nil && p.(int) != 0 {
+		httpPort = int32(p.(int))
+	}
+	if p := mapKeyIfExists(d, "ingress_https_host_port"); p != nil && p.(int) != 0 {
+		httpsPort = int32(p.(int))
+	}
+	return httpPort, httpsPort
+}
+
 func flattenKindConfigNetworking(d map[string]interface{}) v1alpha4.Networking {
 	obj := v1alpha4.Networking{}
 
@@ -253,6 +287,62 @@ func flattenKindConfigExtraPortMappings(d map[string]interface{}) v1alpha4.PortM
 	return obj
 }
 
+// kindConfigRegistryMirrors returns the registry mirrors of the kind_config
+// attribute, if any.
+func kindConfigRegistryMirrors(config interface{}) []registryMirror {
+	cfg, _ := config.([]interface{})
+	if len(cfg) != 1 || cfg[0] == nil {
+		return nil
+	}
+	return flattenKindConfigRegistryMirrors(cfg[0].(map[string]interface{}))
+}
+
+// flattenKindConfigRegistryMirrors returns the registry_mirror blocks of a
+// kind_config. They aren't part of the kind config itself but are written onto
+// the nodes as containerd hosts.toml files once the cluster is created.
+func flattenKindConfigRegistryMirrors(d map[string]interface{}) []registryMirror {
+	mirrors := []registryMirror{}
+
+	registryMirrors := mapKeyIfExists(d, "registry_mirror")
+	if registryMirrors == nil {
+		return mirrors
+	}
+	for _, m := range registryMirrors.([]interface{}) {
+		data := m.(map[string]interface{})
+		obj := registryMirror{}
+
+		registry := mapKeyIfExists(data, "registry")
+		if registry != nil {
+			obj.Registry = registry.(string)
+		}
+		endpoints := mapKeyIfExists(data, "endpoints")
+		if endpoints != nil {
+			for _, e := range endpoints.([]interface{}) {
+				obj.Endpoints = append(obj.Endpoints, e.(string))
+			}
+		}
+		skipVerify := mapKeyIfExists(data, "skip_verify")
+		if skipVerify != nil {
+			obj.SkipVerify = skipVerify.(bool)
+		}
+		caFile := mapKeyIfExists(data, "ca_file")
+		if caFile != nil {
+			obj.CAFile = caFile.(string)
+		}
+		auth := mapKeyIfExists(data, "auth")
+		if auth != nil {
+			if a := auth.([]interface{}); len(a) == 1 && a[0] != nil {
+				authData := a[0].(map[string]interface{})
+				obj.Username = mapKeyIfExists(authData, "username").(string)
+				obj.Password = mapKeyIfExists(authData, "password").(string)
+			}
+		}
+
+		mirrors = append(mirrors, obj)
+	}
+	return mirrors
+}
+
 func mapKeyIfExists(m map[string]interface{}, key string) interface{} {
 	if val, ok := m[key]; ok {
 		return val
@@ -0,0 +1,68 @@
+package kind
+
+import (
+	"testing"
+
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+func TestFlattenKindConfigNodes_IngressReady(t *testing.T) {
+	node := flattenKindConfigNodes(map[string]interface{}{
+		"role":                    "control-plane",
+		"ingress_ready":           true,
+		"ingress_http_host_port":  8080,
+		"ingress_https_host_port": 8443,
+		"extra_port_mappings": []interface{}{
+			map[string]interface{}{"container_port": 30000, "host_port": 30000},
+		},
+	})
+
+	if node.Labels["ingress-ready"] != "true" {
+		t.Errorf("expected ingress-ready=true label, got %v", node.Labels)
+	}
+	expected := []v1alpha4.PortMapping{
+		{ContainerPort: 30000, HostPort: 30000},
+		{ContainerPort: 80, HostPort: 8080, Protocol: v1alpha4.PortMappingProtocolTCP},
+		{ContainerPort: 443, HostPort: 8443, Protocol: v1alpha4.PortMappingProtocolTCP},
+	}
+	if len(node.ExtraPortMappings) != len(expected) {
+		t.Fatalf("expected %d port mappings, got %v", len(expected), node.ExtraPortMappings)
+	}
+	for i := range expected {
+		if node.ExtraPortMappings[i] != expected[i] {
+			t.Errorf("expected port mapping %d to be %+v, got %+v", i, expected[i], node.ExtraPortMappings[i])
+		}
+	}
+}
+
+func TestFlattenKindConfigNodes_NotIngressReady(t *testing.T) {
+	node := flattenKindConfigNodes(map[string]interface{}{
+		"role":                    "worker",
+		"ingress_ready":           false,
+		"ingress_http_host_port":  80,
+		"ingress_https_host_port": 443,
+	})
+	if _, ok := node.Labels["ingress-ready"]; ok {
+		t.Error("expected no ingress-ready label")
+	}
+	if len(node.ExtraPortMappings) != 0 {
+		t.Errorf("expected no port mappings, got %v", node.ExtraPortMappings)
+	}
+}
+
+func TestIngressHostPorts(t *testing.T) {
+	cases := []struct {
+		Node        map[string]interface{}
+		HTTP, HTTPS int32
+	}{
+		{map[string]interface{}{"ingress_ready": true}, 80, 443},
+		{map[string]interface{}{"ingress_ready": true, "ingress_http_host_port": 0, "ingress_https_host_port": 0}, 80, 443},
+		{map[string]interface{}{"ingress_ready": true, "ingress_http_host_port": 8080}, 8080, 443},
+		{map[string]interface{}{"ingress_ready": true, "ingress_http_host_port": 8080, "ingress_https_host_port": 8443}, 8080, 8443},
+	}
+	for _, tc := range cases {
+		if http, https := ingressHostPorts(tc.Node); http != tc.HTTP || https != tc.HTTPS {
+			t.Errorf("%v: expected %d and %d, got %d and %d", tc.Node, tc.HTTP, tc.HTTPS, http, https)
+		}
+	}
+}
@@ -64,3 +64,13 @@ func TestNormalizeTomlString(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandStringList(t *testing.T) {
+	got := expandStringList([]interface{}{"a", "b", nil, "c"})
+	if len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("expected [a b c], got %v", got)
+	}
+	if got := expandStringList(nil); len(got) != 0 {
+		t.Errorf("expected empty list, got %v", got)
+	}
+}
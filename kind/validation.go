@@ -1,7 +1,12 @@
 package kind
 
 import (
+	"crypto/x509"
+	"encoding/pem"
+	goerrors "errors"
 	"fmt"
+
+	kindVersion "sigs.k8s.io/kind/pkg/cmd/kind/version"
 )
 
 func stringIsValidToml(i interface{}, k string) (warnings []string, errors []error) {
@@ -16,3 +21,105 @@ func stringIsValidToml(i interface{}, k string) (warnings []string, errors []err
 	}
 	return warnings, errors
 }
+
+func stringIsPEMCertificate(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+	block, _ := pem.Decode([]byte(v))
+	if block == nil || block.Type != "CERTIFICATE" {
+		errors = append(errors, fmt.Errorf("%s is not a PEM encoded certificate", k))
+		return warnings, errors
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		errors = append(errors, fmt.Errorf("%s is not a valid certificate: %s", k, err))
+	}
+	return warnings, errors
+}
+
+// stringIsDigestPinnedImage warns about image references without a digest,
+// kind node images are built for specific kind versions and a tag may point
+// to an image that doesn't work with the kind version of the provider.
+func stringIsDigestPinnedImage(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+	if v != "" && !imageHasDigest(v) {
+		warnings = append(warnings, fmt.Sprintf("%s %q is not pinned to a digest and may be incompatible with kind v%s, use an image@sha256:... reference from the kind release notes or kubernetes_version", k, v, kindVersion.Version()))
+	}
+	return warnings, errors
+}
+
+// validateIngressReadyNodes checks that the port mappings and labels added for
+// ingress_ready nodes don't clash with what is declared on the kind_config
+// nodes explicitly.
+func validateIngressReadyNodes(nodes []interface{}) error {
+	errs := []error{}
+	usedHostPorts := map[int32]string{}
+
+	// user declared TCP host ports across all nodes
+	for i, n := range nodes {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		mappings, _ := mapKeyIfExists(node, "extra_port_mappings").([]interface{})
+		for j, m := range mappings {
+			data, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			mapping := flattenKindConfigExtraPortMappings(data)
+			if mapping.HostPort != 0 && (mapping.Protocol == "" || mapping.Protocol == "TCP") {
+				usedHostPorts[mapping.HostPort] = fmt.Sprintf("node.%d.extra_port_mappings.%d", i, j)
+			}
+		}
+	}
+
+	for i, n := range nodes {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ready, _ := mapKeyIfExists(node, "ingress_ready").(bool); !ready {
+			continue
+		}
+		path := fmt.Sprintf("node.%d", i)
+
+		if labels, ok := mapKeyIfExists(node, "labels").(map[string]interface{}); ok {
+			if v, ok := labels[ingressReadyLabel]; ok && v != "true" {
+				errs = append(errs, fmt.Errorf("%s.labels sets %s=%v which conflicts with ingress_ready", path, ingressReadyLabel, v))
+			}
+		}
+
+		mappings, _ := mapKeyIfExists(node, "extra_port_mappings").([]interface{})
+		for j, m := range mappings {
+			data, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			mapping := flattenKindConfigExtraPortMappings(data)
+			if (mapping.ContainerPort == 80 || mapping.ContainerPort == 443) && (mapping.Protocol == "" || mapping.Protocol == "TCP") {
+				errs = append(errs, fmt.Errorf("%s.extra_port_mappings.%d maps container port %d which ingress_ready already maps, use ingress_http_host_port or ingress_https_host_port instead", path, j, mapping.ContainerPort))
+			}
+		}
+
+		httpPort, httpsPort := ingressHostPorts(node)
+		if httpPort == httpsPort {
+			errs = append(errs, fmt.Errorf("%s maps both ingress ports to host port %d", path, httpPort))
+		}
+		for _, port := range []int32{httpPort, httpsPort} {
+			if other, ok := usedHostPorts[port]; ok {
+				errs = append(errs, fmt.Errorf("%s ingress host port %d is already used by %s", path, port, other))
+				continue
+			}
+			usedHostPorts[port] = path
+		}
+	}
+
+	return goerrors.Join(errs...)
+}
@@ -0,0 +1,240 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+)
+
+func TestWorkerNodeName(t *testing.T) {
+	cases := map[int]string{
+		0: "test-worker",
+		1: "test-worker2",
+		4: "test-worker5",
+	}
+	for index, expected := range cases {
+		if got := workerNodeName("test", index); got != expected {
+			t.Errorf("expected %q for index %d, got %q", expected, index, got)
+		}
+	}
+}
+
+func TestWorkerScalingOnly(t *testing.T) {
+	controlPlane := map[string]interface{}{"role": "control-plane"}
+	worker := map[string]interface{}{"role": "worker"}
+	labeledWorker := map[string]interface{}{"role": "worker", "labels": map[string]interface{}{"tier": "backend"}}
+	patchedWorker := map[string]interface{}{"role": "worker", "kubeadm_config_patches": []interface{}{"kind: JoinConfiguration"}}
+
+	cases := []struct {
+		Name     string
+		Old      []interface{}
+		New      []interface{}
+		Expected bool
+	}{
+		{Name: "AddWorker", Old: []interface{}{controlPlane}, New: []interface{}{controlPlane, worker}, Expected: true},
+		{Name: "AddLabeledWorker", Old: []interface{}{controlPlane, worker}, New: []interface{}{controlPlane, worker, labeledWorker}, Expected: true},
+		{Name: "RemoveWorker", Old: []interface{}{controlPlane, worker, worker}, New: []interface{}{controlPlane, worker}, Expected: true},
+		{Name: "RemoveAllWorkers", Old: []interface{}{controlPlane, worker}, New: []interface{}{controlPlane}, Expected: true},
+		{Name: "AddControlPlane", Old: []interface{}{controlPlane}, New: []interface{}{controlPlane, controlPlane}},
+		{Name: "ChangeExistingWorker", Old: []interface{}{controlPlane, worker}, New: []interface{}{controlPlane, labeledWorker}},
+		{Name: "RemoveMiddleWorker", Old: []interface{}{controlPlane, labeledWorker, worker}, New: []interface{}{controlPlane, worker}},
+		{Name: "AddWorkerWithKubeadmPatches", Old: []interface{}{controlPlane}, New: []interface{}{controlPlane, patchedWorker}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if got := workerScalingOnly(tc.Old, tc.New); got != tc.Expected {
+				t.Errorf("expected %t, got %t", tc.Expected, got)
+			}
+		})
+	}
+}
+
+func TestWorkerRunArgs(t *testing.T) {
+	node := v1alpha4.Node{
+		Role:  v1alpha4.WorkerRole,
+		Image: "kindest/node:v1.29.7",
+		ExtraMounts: []v1alpha4.Mount{
+			{HostPath: "/data", ContainerPath: "/mnt/data", Readonly: true, Propagation: v1alpha4.MountPropagationHostToContainer},
+		},
+		ExtraPortMappings: []v1alpha4.PortMapping{
+			{ContainerPort: 30080, HostPort: 8080},
+			{ContainerPort: 30053, Protocol: v1alpha4.PortMappingProtocolUDP, ListenAddress: "127.0.0.1"},
+		},
+	}
+	args := workerRunArgs("test", "test-worker2", "kind", node, v1alpha4.IPv4Family, []string{"HTTP_PROXY=http://proxy:3128"})
+
+	for _, expected := range [][]string{
+		{"--name", "test-worker2"},
+		{"--label", "io.x-k8s.kind.cluster=test"},
+		{"--label", "io.x-k8s.kind.role=worker"},
+		{"--net", "kind"},
+		{"-e", "HTTP_PROXY=http://proxy:3128"},
+		{"--volume=/data:/mnt/data:ro,rslave"},
+		{"--publish=0.0.0.0:8080:30080/TCP"},
+		{"--publish=127.0.0.1::30053/UDP"},
+	} {
+		if !containsArgs(args, expected) {
+			t.Errorf("expected args to contain %v, got %v", expected, args)
+		}
+	}
+	if last := args[len(args)-1]; last != node.Image {
+		t.Errorf("expected image %q as last argument, got %q", node.Image, last)
+	}
+}
+
+func containsArgs(args, expected []string) bool {
+	for i := 0; i+len(expected) <= len(args); i++ {
+		if reflect.DeepEqual(args[i:i+len(expected)], expected) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResourceClusterCustomizeDiff_WorkerScaling(t *testing.T) {
+	cases := []struct {
+		Name              string
+		OldRoles          []string
+		NewRoles          []string
+		ExpectRequiresNew bool
+	}{
+		{Name: "AddWorker", OldRoles: []string{"control-plane", "worker"}, NewRoles: []string{"control-plane", "worker", "worker"}},
+		{Name: "RemoveWorker", OldRoles: []string{"control-plane", "worker", "worker"}, NewRoles: []string{"control-plane", "worker"}},
+		{Name: "AddControlPlane", OldRoles: []string{"control-plane"}, NewRoles: []string{"control-plane", "control-plane"}, ExpectRequiresNew: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			attributes := map[string]string{
+				"id":                        "test-kindest/node",
+				"name":                      "test",
+				"running":                   "true",
+				"wait_for_ready":            "false",
+				"retain_on_failure":         "false",
+				"kind_config.#":             "1",
+				"kind_config.0.kind":        "Cluster",
+				"kind_config.0.api_version": "kind.x-k8s.io/v1alpha4",
+				"kind_config.0.node.#":      fmt.Sprintf("%d", len(tc.OldRoles)),
+			}
+			for i, role := range tc.OldRoles {
+				prefix := fmt.Sprintf("kind_config.0.node.%d.", i)
+				attributes[prefix+"role"] = role
+				attributes[prefix+"ingress_ready"] = "false"
+			}
+			state := &terraform.InstanceState{ID: "test-kindest/node", Attributes: attributes}
+
+			nodes := []interface{}{}
+			for _, role := range tc.NewRoles {
+				nodes = append(nodes, map[string]interface{}{"role": role})
+			}
+			config := terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name": "test",
+				"kind_config": []interface{}{
+					map[string]interface{}{
+						"kind":        "Cluster",
+						"api_version": "kind.x-k8s.io/v1alpha4",
+						"node":        nodes,
+					},
+				},
+			})
+
+			diff, err := resourceCluster().Diff(context.Background(), state, config, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff == nil || diff.Empty() {
+				t.Fatal("expected a diff")
+			}
+			if got := diff.RequiresNew(); got != tc.ExpectRequiresNew {
+				t.Errorf("expected RequiresNew %t, got %t", tc.ExpectRequiresNew, got)
+			}
+		})
+	}
+}
+
+func TestResourceClusterCustomizeDiff_NodeChanges(t *testing.T) {
+	attributes := map[string]string{
+		"id":                        "test-kindest/node",
+		"name":                      "test",
+		"running":                   "true",
+		"wait_for_ready":            "false",
+		"retain_on_failure":         "false",
+		"kind_config.#":             "1",
+		"kind_config.0.kind":        "Cluster",
+		"kind_config.0.api_version": "kind.x-k8s.io/v1alpha4",
+		"kind_config.0.node.#":      "2",
+		"kind_config.0.node.0.role": "control-plane",
+		"kind_config.0.node.1.role": "worker",
+		"kind_config.0.node.1.extra_port_mappings.#":                "1",
+		"kind_config.0.node.1.extra_port_mappings.0.container_port": "30000",
+		"kind_config.0.node.1.extra_port_mappings.0.host_port":      "30000",
+	}
+	for i := 0; i < 2; i++ {
+		prefix := fmt.Sprintf("kind_config.0.node.%d.", i)
+		attributes[prefix+"ingress_ready"] = "false"
+	}
+	state := &terraform.InstanceState{ID: "test-kindest/node", Attributes: attributes}
+
+	cases := []struct {
+		Name   string
+		Modify func(controlPlane, worker map[string]interface{})
+	}{
+		{
+			Name:   "ControlPlaneImage",
+			Modify: func(cp, w map[string]interface{}) { cp["image"] = "kindest/node:v1.35.0" },
+		},
+		{
+			Name:   "WorkerLabels",
+			Modify: func(cp, w map[string]interface{}) { w["labels"] = map[string]interface{}{"tier": "backend"} },
+		},
+		{
+			Name: "WorkerKubeadmConfigPatches",
+			Modify: func(cp, w map[string]interface{}) {
+				w["kubeadm_config_patches"] = []interface{}{"kind: JoinConfiguration"}
+			},
+		},
+		{
+			Name: "WorkerPortMapping",
+			Modify: func(cp, w map[string]interface{}) {
+				w["extra_port_mappings"] = []interface{}{map[string]interface{}{"container_port": 30000, "host_port": 30001}}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			controlPlane := map[string]interface{}{"role": "control-plane"}
+			worker := map[string]interface{}{
+				"role":                "worker",
+				"extra_port_mappings": []interface{}{map[string]interface{}{"container_port": 30000, "host_port": 30000}},
+			}
+			tc.Modify(controlPlane, worker)
+			config := terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name": "test",
+				"kind_config": []interface{}{
+					map[string]interface{}{
+						"kind":        "Cluster",
+						"api_version": "kind.x-k8s.io/v1alpha4",
+						"node":        []interface{}{controlPlane, worker},
+					},
+				},
+			})
+
+			diff, err := resourceCluster().Diff(context.Background(), state, config, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff == nil || diff.Empty() {
+				t.Fatal("expected a diff")
+			}
+			if !diff.RequiresNew() {
+				t.Errorf("expected a node change to require a new cluster, got %#v", diff.Attributes)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+#!/usr/bin/env bash
+# Regenerates kind/node_images.txt from the release notes of the kind version
+# in go.mod, which list the node images built for that release.
+set -o errexit -o pipefail -o nounset
+
+readonly OUTPUT=kind/node_images.txt
+
+version=$(go list -m -f '{{.Version}}' sigs.k8s.io/kind)
+images=$(curl -fsSL "https://api.github.com/repos/kubernetes-sigs/kind/releases/tags/${version}" \
+	| grep -oE 'kindest/node:v[0-9]+\.[0-9]+\.[0-9]+@sha256:[0-9a-f]{64}' \
+	| sort -u -V)
+if [ -z "${images}" ]; then
+	echo "no node images found in the release notes of kind ${version}" >&2
+	exit 1
+fi
+
+{
+	echo "# Node images published with the kind version in go.mod, one digest pinned"
+	echo "# reference per Kubernetes minor version, as listed in the kind release notes."
+	echo "# Regenerate with \`make update-node-images\` whenever sigs.k8s.io/kind is bumped."
+	echo "${images}"
+} > "${OUTPUT}"
+echo "updated ${OUTPUT} with $(echo "${images}" | wc -l | tr -d ' ') images of kind ${version}"